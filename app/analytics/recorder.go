@@ -0,0 +1,139 @@
+package analytics
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+// maxBufferFactor limit how many batch kept in memory when database is down
+const maxBufferFactor = 10
+
+// Recorder buffers playback event in memory and flush them to database
+type Recorder struct {
+	db     *gorm.DB
+	size   int
+	mu     sync.Mutex
+	buffer []model.PlaybackEvent
+}
+
+// NewRecorder create recorder which flush every size events
+func NewRecorder(db *gorm.DB, size int) *Recorder {
+	return &Recorder{
+		db:     db,
+		size:   size,
+		buffer: make([]model.PlaybackEvent, 0, size),
+	}
+}
+
+// Add queue events, flush right away when buffer is full
+func (r *Recorder) Add(events ...model.PlaybackEvent) {
+	r.mu.Lock()
+	r.buffer = append(r.buffer, events...)
+
+	// Drop the oldest events instead of growing forever
+	if max := r.size * maxBufferFactor; len(r.buffer) > max {
+		r.buffer = r.buffer[len(r.buffer)-max:]
+	}
+	full := len(r.buffer) >= r.size
+	r.mu.Unlock()
+
+	if full {
+		go r.Flush()
+	}
+}
+
+// Flush write buffered events to database.
+// Events are put back to the buffer when it fails
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	events := r.buffer
+	r.buffer = make([]model.PlaybackEvent, 0, r.size)
+	r.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	resolveShow(r.db, events)
+
+	tx := r.db.Begin()
+	for i := range events {
+		if err := tx.Create(&events[i]).Error; err != nil {
+			tx.Rollback()
+			r.restore(events)
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		r.restore(events)
+		return err
+	}
+	return nil
+}
+
+// Run flush the buffer periodically, it blocks so call it on goroutine
+func (r *Recorder) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := r.Flush(); err != nil {
+			log.Println("analytics: flush events:", err)
+		}
+	}
+}
+
+func (r *Recorder) restore(events []model.PlaybackEvent) {
+	for i := range events {
+		events[i].ID = 0
+	}
+
+	r.mu.Lock()
+	r.buffer = append(events, r.buffer...)
+	r.mu.Unlock()
+}
+
+// resolveShow fill show of each event from its player or video
+func resolveShow(db *gorm.DB, events []model.PlaybackEvent) {
+	playerIDs := []int{}
+	videoIDs := []int{}
+	for _, event := range events {
+		if event.PlayerID != 0 {
+			playerIDs = append(playerIDs, event.PlayerID)
+		} else if event.VideoID != 0 {
+			videoIDs = append(videoIDs, event.VideoID)
+		}
+	}
+
+	players := []model.Player{}
+	if len(playerIDs) > 0 {
+		db.Where("id IN (?)", playerIDs).Find(&players)
+	}
+	videos := []model.Video{}
+	if len(videoIDs) > 0 {
+		db.Where("id IN (?)", videoIDs).Find(&videos)
+	}
+
+	playerShow := map[int]model.Player{}
+	for _, player := range players {
+		playerShow[int(player.ID)] = player
+	}
+	videoShow := map[int]model.Video{}
+	for _, video := range videos {
+		videoShow[int(video.ID)] = video
+	}
+
+	for i, event := range events {
+		if player, ok := playerShow[event.PlayerID]; ok {
+			events[i].ShowID = player.ShowID
+			events[i].ShowType = player.ShowType
+		} else if video, ok := videoShow[event.VideoID]; ok {
+			events[i].ShowID = video.ShowID
+			events[i].ShowType = video.ShowType
+		}
+	}
+}
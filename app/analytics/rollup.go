@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"log"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+// DateFormat is format used by TitleStat.Date
+const DateFormat = "2006-01-02"
+
+// Rollup aggregate playback events of a day into title stats.
+// It replaces existing stats of that day so it is safe to run again
+func Rollup(db *gorm.DB, day time.Time) error {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	date := start.Format(DateFormat)
+
+	stats := []model.TitleStat{}
+	if err := db.Raw(`SELECT show_type, show_id,
+			COUNT(DISTINCT CASE WHEN type = ? THEN session_id END) AS views,
+			COUNT(DISTINCT viewer) AS unique_viewers,
+			COUNT(DISTINCT CASE WHEN type = ? THEN session_id END) AS completions,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS errors
+		FROM playback_events
+		WHERE deleted_at IS NULL AND show_id <> 0 AND occurred_at >= ? AND occurred_at < ?
+		GROUP BY show_type, show_id`,
+		model.EventPlay, model.EventComplete, model.EventError, start, end).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	if err := tx.Unscoped().Where("date = ?", date).Delete(&model.TitleStat{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, stat := range stats {
+		stat.Date = date
+		if stat.Views > 0 {
+			stat.CompletionRate = float64(stat.Completions) / float64(stat.Views)
+		}
+		if err := tx.Create(&stat).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// RunRollup refresh today and yesterday stats periodically.
+// Yesterday is included so late events are still counted
+func RunRollup(db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		now := time.Now()
+		for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+			if err := Rollup(db, day); err != nil {
+				log.Println("analytics: rollup", day.Format(DateFormat), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+// trendingPeriod describe how far back activity is counted and how fast it decays
+type trendingPeriod struct {
+	Days     int
+	HalfLife time.Duration
+}
+
+var trendingPeriods = map[string]trendingPeriod{
+	model.TrendingDay:  {Days: 1, HalfLife: 6 * time.Hour},
+	model.TrendingWeek: {Days: 7, HalfLife: 48 * time.Hour},
+}
+
+// Weight of each activity in trending score
+var activityWeights = map[string]float64{
+	model.EventPlay:     1,
+	model.EventComplete: 0.5,
+}
+
+// RefreshTrending recompute trending score of every show for the period.
+// Each playback session count once per activity, episode count to its tv show
+func RefreshTrending(db *gorm.DB, period string) error {
+	trending, ok := trendingPeriods[period]
+	if !ok {
+		return fmt.Errorf("unknown trending period %q", period)
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -trending.Days)
+
+	type activity struct {
+		ShowID     int
+		ShowType   string
+		Type       string
+		OccurredAt time.Time
+	}
+
+	activities := []activity{}
+	if err := db.Raw(`SELECT
+			CASE WHEN e.show_type = 'tv_episodes' THEN s.tv_id ELSE e.show_id END AS show_id,
+			CASE WHEN e.show_type = 'tv_episodes' THEN 'tvs' ELSE e.show_type END AS show_type,
+			e.type AS type,
+			MIN(e.occurred_at) AS occurred_at
+		FROM playback_events e
+		LEFT JOIN tv_episodes ep ON (e.show_type = 'tv_episodes' AND ep.id = e.show_id)
+		LEFT JOIN tv_seasons s ON s.id = ep.tv_season_id
+		WHERE e.deleted_at IS NULL AND e.show_id <> 0 AND e.type IN (?) AND e.occurred_at >= ?
+		GROUP BY 1, 2, e.type, e.session_id`,
+		[]string{model.EventPlay, model.EventComplete}, since).
+		Scan(&activities).Error; err != nil {
+		return err
+	}
+
+	scores := map[string]*model.TrendingScore{}
+	for _, v := range activities {
+		if v.ShowID == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%d", v.ShowType, v.ShowID)
+		score, ok := scores[key]
+		if !ok {
+			score = &model.TrendingScore{Period: period, ShowID: v.ShowID, ShowType: v.ShowType}
+			scores[key] = score
+		}
+
+		age := now.Sub(v.OccurredAt)
+		score.Score += activityWeights[v.Type] * math.Pow(0.5, float64(age)/float64(trending.HalfLife))
+		if v.Type == model.EventPlay {
+			score.Plays++
+		}
+	}
+
+	tx := db.Begin()
+	if err := tx.Unscoped().Where("period = ?", period).Delete(&model.TrendingScore{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, score := range scores {
+		if err := tx.Create(score).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// RunTrending refresh trending score of all periods periodically
+func RunTrending(db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		for period := range trendingPeriods {
+			if err := RefreshTrending(db, period); err != nil {
+				log.Println("analytics: refresh trending", period, err)
+			}
+		}
+	}
+}
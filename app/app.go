@@ -4,10 +4,21 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/condrowiyono/ruangtengah-api/app/analytics"
+	"github.com/condrowiyono/ruangtengah-api/app/cache"
+	"github.com/condrowiyono/ruangtengah-api/app/gallery"
 	"github.com/condrowiyono/ruangtengah-api/app/handler"
 	"github.com/condrowiyono/ruangtengah-api/app/handler/scrapper"
+	"github.com/condrowiyono/ruangtengah-api/app/healthcheck"
+	"github.com/condrowiyono/ruangtengah-api/app/imagesearch"
+	"github.com/condrowiyono/ruangtengah-api/app/library"
 	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
 	"github.com/condrowiyono/ruangtengah-api/config"
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
@@ -16,12 +27,85 @@ import (
 
 // App has router and db instances
 type App struct {
-	Router *mux.Router
-	DB     *gorm.DB
+	Router      *mux.Router
+	DB          *gorm.DB
+	Recorder    *analytics.Recorder
+	Storage     storage.Storage
+	Providers   provider.Providers
+	ImageSearch *imagesearch.Searcher
+	Cache       *cache.Cache
+	CacheTTLs   cache.TTLs
 }
 
 // Initialize with predefined configuration
 func (a *App) Initialize(config *config.Config) {
+	a.connect(config)
+
+	store, err := storage.New(config.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Storage = store
+
+	a.setProviders()
+	a.ImageSearch = imagesearch.FromEnv(a.Providers).WithCache(a.Cache, a.CacheTTLs)
+
+	// Posters and banners saved before image roles
+	if attached, err := gallery.AttachLegacyImages(a.DB); err != nil {
+		log.Println("gallery: attach legacy images", err)
+	} else if attached > 0 {
+		log.Printf("gallery: attached %d legacy images", attached)
+	}
+
+	a.Router = mux.NewRouter()
+	a.setRouters()
+
+	// Playback analytics
+	a.Recorder = analytics.NewRecorder(a.DB, 500)
+	go a.Recorder.Run(10 * time.Second)
+	go analytics.RunRollup(a.DB, time.Hour)
+	go analytics.RunTrending(a.DB, 15*time.Minute)
+
+	// Source health check
+	go healthcheck.NewChecker(a.DB, 8, 10*time.Second).Run(6 * time.Hour)
+
+	// Media library
+	go library.NewScannerFromEnv(a.DB, a.Providers).Run(time.Hour)
+
+	// Copy hotlinked images into our storage
+	go gallery.RunMirror(a.DB, a.Storage, 10*time.Minute)
+	go gallery.RunBackfill(a.DB, a.Storage, time.Hour)
+
+	// Unused images are only reported unless IMAGE_GC_DRY_RUN=false
+	go gallery.RunGC(a.DB, a.Storage, gallery.GraceFromEnv(), os.Getenv("IMAGE_GC_DRY_RUN") != "false", 24*time.Hour)
+}
+
+// ScanLibraryOnce scans the media library once and print the report, used by the scan command
+func (a *App) ScanLibraryOnce(config *config.Config) {
+	a.connect(config)
+	defer a.DB.Close()
+	a.setProviders()
+
+	report, err := library.NewScannerFromEnv(a.DB, a.Providers).Scan()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Scanned %d, created %d, existing %d, unmatched %d\n", report.Scanned, report.Created, report.Existing, len(report.Unmatched))
+	for _, unmatched := range report.Unmatched {
+		fmt.Printf("  %s: %s\n", unmatched.Path, unmatched.Reason)
+	}
+}
+
+// setProviders creates the providers, their responses are cached to save the API quota
+func (a *App) setProviders() {
+	a.Cache = cache.FromEnv()
+	a.CacheTTLs = cache.TTLsFromEnv()
+	a.Providers = provider.FromEnv().WithCache(a.Cache, a.CacheTTLs)
+}
+
+// connect open and migrate the database
+func (a *App) connect(config *config.Config) {
 	dbURI := fmt.Sprintf("%s:%s@(%s:%s)/%s?charset=%s&parseTime=True",
 		config.DB.Username,
 		config.DB.Password,
@@ -36,8 +120,13 @@ func (a *App) Initialize(config *config.Config) {
 	}
 
 	a.DB = model.DBMigrate(db)
-	a.Router = mux.NewRouter()
-	a.setRouters()
+
+	// Register never grants admin, the first admins come from ADMIN_USERNAMES
+	if usernames := os.Getenv("ADMIN_USERNAMES"); len(usernames) != 0 {
+		if err := model.SeedAdmins(a.DB, strings.Split(usernames, ",")); err != nil {
+			log.Println("seed admins", err)
+		}
+	}
 }
 
 // Set all required routers
@@ -53,31 +142,43 @@ func (a *App) setRouters() {
 	a.Post("/login", a.Login)
 	a.Post("/register", a.Register)
 	a.GetWithAuth("/me", a.GetMyDetail)
-
-	// Partner 3rd party provider, thanks them
-	a.GetWithAuth("/partner/tmdb/search", a.GetSearchMovie)
-	a.GetWithAuth("/partner/tmdb/movie-detail", a.GetMovieDetail)
-
-	a.GetWithAuth("/partner/tmdb/tv-detail", a.GetTvDetail)
-	a.GetWithAuth("/partner/tmdb/tv-season", a.GetTvSeason)
-	a.GetWithAuth("/partner/tmdb/tv-episode", a.GetTvEpisode)
-
-	a.GetWithAuth("/partner/tmdb/movie-image", a.GetMovieImage)
-	a.GetWithAuth("/partner/tmdb/tv-image", a.GetTvImage)
+	a.PutWithAuth("/me/parental-control", a.UpdateParentalControl)
+
+	// Viewer profiles of logged in user
+	a.GetWithAuth("/profile", a.GetAllProfile)
+	a.PostWithAuth("/profile", a.CreateProfile)
+	a.PutWithAuth("/profile/{id}", a.UpdateProfile)
+	a.DeleteWithAuth("/profile/{id}", a.DeleteProfile)
+	a.PostWithAuth("/profile/{id}/select", a.SelectProfile)
+
+	// Partner 3rd party provider, thanks them. Provider is tmdb or omdb
+	a.GetWithAuth("/partner/{provider}/search", a.GetSearchMovie)
+	a.GetWithAuth("/partner/{provider}/movie-detail", a.GetMovieDetail)
+
+	a.GetWithAuth("/partner/{provider}/tv-detail", a.GetTvDetail)
+	a.GetWithAuth("/partner/{provider}/tv-season", a.GetTvSeason)
+	a.GetWithAuth("/partner/{provider}/tv-episode", a.GetTvEpisode)
+
+	a.GetWithAuth("/partner/{provider}/movie-image", a.GetMovieImage)
+	a.GetWithAuth("/partner/{provider}/tv-image", a.GetTvImage)
+	a.GetWithAuth("/partner/{provider}/person-search", a.SearchPerson)
+	a.GetWithAuth("/partner/{provider}/person-detail", a.GetPersonDetail)
 	a.GetWithAuth("/partner/duckduckgo/image-search", a.GetDuckDuckGoImage)
 	a.GetWithAuth("/partner/google/image-search", a.GetGoogleImage)
+	a.GetWithAuth("/partner/images", a.SearchImages)
 
 	// Person resources
 	a.Get("/person", a.GetAllPerson)
 	a.PostWithAuth("/person", a.CreatePerson)
+	a.PostWithAuth("/person/import", a.ImportPerson)
 	a.Get("/person/{id}", a.GetPerson)
 	a.PutWithAuth("/person/{id}", a.UpdatePerson)
 	a.DeleteWithAuth("/person/{id}", a.DeletePerson)
 
 	// Movie Resource
-	a.Get("/movie", a.GetAllMovie)
+	a.GetWithOptionalAuth("/movie", a.GetAllMovie)
 	a.PostWithAuth("/movie", a.CreateMovie)
-	a.Get("/movie/{id}", a.GetMovie)
+	a.GetWithOptionalAuth("/movie/{id}", a.GetMovie)
 	a.PutWithAuth("/movie/{id}", a.UpdateMovie)
 	a.DeleteWithAuth("/movie/{id}", a.DeleteMovie)
 
@@ -95,17 +196,83 @@ func (a *App) setRouters() {
 	a.PutWithAuth("/image/{id}", a.UpdateImage)
 	a.DeleteWithAuth("/image/{id}", a.DeleteImage)
 	a.Post("/image/upload-image", a.UploadImage)
+	a.PostWithAuth("/image/import-url", a.ImportImageURL)
+	a.Get("/img/{id}", a.GetResizedImage)
+
+	// Ordered images of movie, tv, season, episode, concert and person by role
+	images := "/{owner:movie|tv|season|episode|concert|person}/{id}/images"
+	a.Get(images, a.GetImageAttachments)
+	a.PostWithAuth(images, a.AttachImage)
+	a.PutWithAuth(images+"/reorder", a.ReorderImages)
+	a.DeleteWithAuth(images+"/{attachment}", a.DetachImage)
+	a.PutWithAuth(images+"/{attachment}/primary", a.SetPrimaryImage)
 
 	// Player Resources
-	a.Get("/player", a.GetPlayer)
+	a.GetWithOptionalAuth("/player", a.GetPlayer)
+	a.GetWithOptionalAuth("/play/{id}", a.Play)
+	a.GetWithOptionalAuth("/play/{id}/{file:.+}", a.PlayFile)
 	a.Get("/video", a.GetVideo)
+	// Raw player URL is only for admin, others play through the signed URL
+	a.GetWithAdmin("/players", a.GetShowPlayers)
+	a.PostWithAdmin("/player", a.CreatePlayer)
+	a.PutWithAdmin("/player/reorder", a.ReorderPlayer)
+	a.PutWithAdmin("/player/{id}", a.UpdatePlayer)
+	a.DeleteWithAdmin("/player/{id}", a.DeletePlayer)
+	a.PostWithAdmin("/player/{id}/subtitle", a.UploadSubtitle)
+	a.PutWithAdmin("/subtitle/{id}", a.UpdateSubtitle)
+	a.DeleteWithAdmin("/subtitle/{id}", a.DeleteSubtitle)
 
 	// Concert Resource
-	a.Get("/concert", a.GetAllConcert)
+	a.GetWithOptionalAuth("/concert", a.GetAllConcert)
 	a.PostWithAuth("/concert", a.CreateConcert)
-	a.Get("/concert/{id}", a.GetConcert)
+	a.GetWithOptionalAuth("/concert/{id}", a.GetConcert)
 	a.PutWithAuth("/concert/{id}", a.UpdateConcert)
 	a.DeleteWithAuth("/concert/{id}", a.DeleteConcert)
+
+	// Home screen and its rails
+	a.GetWithOptionalAuth("/home", a.GetHome)
+	a.GetWithOptionalAuth("/trending", a.GetTrending)
+	a.Get("/rail", a.GetAllRail)
+	a.PostWithAuth("/rail", a.CreateRail)
+	a.PutWithAuth("/rail/reorder", a.ReorderRail)
+	a.Get("/rail/{id}", a.GetRail)
+	a.PutWithAuth("/rail/{id}", a.UpdateRail)
+	a.DeleteWithAuth("/rail/{id}", a.DeleteRail)
+
+	// Curated list resources, only admin manages them and sees the draft list
+	a.GetWithOptionalAuth("/curated-list", a.GetAllCuratedList)
+	a.PostWithAdmin("/curated-list", a.CreateCuratedList)
+	a.GetWithOptionalAuth("/curated-list/{id}", a.GetCuratedList)
+	a.PutWithAdmin("/curated-list/{id}", a.UpdateCuratedList)
+	a.DeleteWithAdmin("/curated-list/{id}", a.DeleteCuratedList)
+	a.PutWithAdmin("/curated-list/{id}/reorder", a.ReorderCuratedList)
+
+	// Playback analytics
+	a.PostWithOptionalAuth("/events", a.CreateEvents)
+	a.GetWithAdmin("/stats/titles", a.GetTitleStats)
+
+	// Source health
+	a.GetWithAdmin("/admin/broken-links", a.GetBrokenLinks)
+
+	// Users
+	a.PutWithAdmin("/admin/users/{id}/role", a.SetUserRole)
+
+	// Media library
+	a.PostWithAdmin("/admin/library/scan", a.ScanLibrary)
+	a.GetWithAdmin("/admin/library/unmatched", a.GetUnmatchedMedia)
+	a.PostWithAdmin("/admin/library/unmatched/{id}/match", a.MatchUnmatchedMedia)
+
+	// Image deduplication
+	a.GetWithAdmin("/admin/images/duplicates", a.GetDuplicateImages)
+	a.PostWithAdmin("/admin/images/merge", a.MergeImages)
+
+	// Unused images
+	a.GetWithAdmin("/admin/images/gc", a.GetImageGarbage)
+	a.PostWithAdmin("/admin/images/gc", a.CollectImageGarbage)
+
+	// Provider cache
+	a.GetWithAdmin("/admin/provider-cache", a.GetProviderCache)
+	a.PostWithAdmin("/admin/provider-cache/purge", a.PurgeProviderCache)
 }
 
 // HealthzCheck handler
@@ -120,6 +287,11 @@ func (a *App) Login(w http.ResponseWriter, r *http.Request) {
 	handler.Login(a.DB, w, r)
 }
 
+// SetUserRole handler
+func (a *App) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	handler.SetUserRole(a.DB, w, r)
+}
+
 // Register handle user register
 func (a *App) Register(w http.ResponseWriter, r *http.Request) {
 	handler.Register(a.DB, w, r)
@@ -130,48 +302,98 @@ func (a *App) GetMyDetail(w http.ResponseWriter, r *http.Request) {
 	handler.GetMyDetail(a.DB, w, r)
 }
 
+// UpdateParentalControl handle logged in user maximum rating
+func (a *App) UpdateParentalControl(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateParentalControl(a.DB, w, r)
+}
+
+// PROFILE
+
+// GetAllProfile handler
+func (a *App) GetAllProfile(w http.ResponseWriter, r *http.Request) {
+	handler.GetAllProfile(a.DB, w, r)
+}
+
+// CreateProfile handler
+func (a *App) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	handler.CreateProfile(a.DB, w, r)
+}
+
+// UpdateProfile handler
+func (a *App) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateProfile(a.DB, w, r)
+}
+
+// DeleteProfile handler
+func (a *App) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	handler.DeleteProfile(a.DB, w, r)
+}
+
+// SelectProfile handler
+func (a *App) SelectProfile(w http.ResponseWriter, r *http.Request) {
+	handler.SelectProfile(a.DB, w, r)
+}
+
 // PARTNER
 
 // GetMovieDetail handler
 func (a *App) GetMovieDetail(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetMovieDetail(w, r)
+	scrapper.GetMovieDetail(a.Providers, w, r)
 }
 
 // GetMovieImage handler
 func (a *App) GetMovieImage(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetMovieImage(w, r)
+	scrapper.GetMovieImage(a.Providers, w, r)
 }
 
 // GetSearchMovie handler
 func (a *App) GetSearchMovie(w http.ResponseWriter, r *http.Request) {
-	scrapper.SearchMovie(w, r)
+	scrapper.SearchMovie(a.Providers, w, r)
 }
 
 // GetDuckDuckGoImage handler
 func (a *App) GetDuckDuckGoImage(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetDuckDuckGoImage(w, r)
+	scrapper.GetDuckDuckGoImage(a.Cache, a.CacheTTLs, w, r)
 }
 
 // GetGoogleImage handler
 func (a *App) GetGoogleImage(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetGoogleImage(w, r)
+	scrapper.GetGoogleImage(a.Cache, a.CacheTTLs, w, r)
+}
+
+// SearchImages handler
+func (a *App) SearchImages(w http.ResponseWriter, r *http.Request) {
+	scrapper.SearchImages(a.ImageSearch, a.Providers, w, r)
 }
 
-// GetTvImage s
+// GetTvImage handler
 func (a *App) GetTvImage(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetTvImage(w, r)
+	scrapper.GetTvImage(a.Providers, w, r)
 }
 
+// GetTvDetail handler
 func (a *App) GetTvDetail(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetTvDetail(w, r)
+	scrapper.GetTvDetail(a.Providers, w, r)
 }
 
+// GetTvSeason handler
 func (a *App) GetTvSeason(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetTvSeason(w, r)
+	scrapper.GetTvSeason(a.Providers, w, r)
 }
 
+// GetTvEpisode handler
 func (a *App) GetTvEpisode(w http.ResponseWriter, r *http.Request) {
-	scrapper.GetTvEpisode(w, r)
+	scrapper.GetTvEpisode(a.Providers, w, r)
+}
+
+// SearchPerson handler
+func (a *App) SearchPerson(w http.ResponseWriter, r *http.Request) {
+	scrapper.SearchPerson(a.Providers, w, r)
+}
+
+// GetPersonDetail handler
+func (a *App) GetPersonDetail(w http.ResponseWriter, r *http.Request) {
+	scrapper.GetPersonDetail(a.Providers, w, r)
 }
 
 // PERSON
@@ -186,6 +408,11 @@ func (a *App) CreatePerson(w http.ResponseWriter, r *http.Request) {
 	handler.CreatePerson(a.DB, w, r)
 }
 
+// ImportPerson handler
+func (a *App) ImportPerson(w http.ResponseWriter, r *http.Request) {
+	handler.ImportPerson(a.DB, a.Storage, a.Providers, w, r)
+}
+
 // GetPerson Handler
 func (a *App) GetPerson(w http.ResponseWriter, r *http.Request) {
 	handler.GetPerson(a.DB, w, r)
@@ -284,7 +511,72 @@ func (a *App) DeleteImage(w http.ResponseWriter, r *http.Request) {
 
 // UploadImage handler
 func (a *App) UploadImage(w http.ResponseWriter, r *http.Request) {
-	handler.UploadImage(a.DB, w, r)
+	handler.UploadImage(a.DB, a.Storage, w, r)
+}
+
+// ImportImageURL handler
+func (a *App) ImportImageURL(w http.ResponseWriter, r *http.Request) {
+	handler.ImportImageURL(a.DB, a.Storage, w, r)
+}
+
+// GetDuplicateImages handler
+func (a *App) GetDuplicateImages(w http.ResponseWriter, r *http.Request) {
+	handler.GetDuplicateImages(a.DB, w, r)
+}
+
+// MergeImages handler
+func (a *App) MergeImages(w http.ResponseWriter, r *http.Request) {
+	handler.MergeImages(a.DB, w, r)
+}
+
+// GetImageAttachments handler
+func (a *App) GetImageAttachments(w http.ResponseWriter, r *http.Request) {
+	handler.GetImageAttachments(a.DB, w, r)
+}
+
+// AttachImage handler
+func (a *App) AttachImage(w http.ResponseWriter, r *http.Request) {
+	handler.AttachImage(a.DB, w, r)
+}
+
+// ReorderImages handler
+func (a *App) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	handler.ReorderImages(a.DB, w, r)
+}
+
+// DetachImage handler
+func (a *App) DetachImage(w http.ResponseWriter, r *http.Request) {
+	handler.DetachImage(a.DB, w, r)
+}
+
+// SetPrimaryImage handler
+func (a *App) SetPrimaryImage(w http.ResponseWriter, r *http.Request) {
+	handler.SetPrimaryImage(a.DB, w, r)
+}
+
+// GetImageGarbage handler
+func (a *App) GetImageGarbage(w http.ResponseWriter, r *http.Request) {
+	handler.GetImageGarbage(a.DB, a.Storage, w, r)
+}
+
+// CollectImageGarbage handler
+func (a *App) CollectImageGarbage(w http.ResponseWriter, r *http.Request) {
+	handler.CollectImageGarbage(a.DB, a.Storage, w, r)
+}
+
+// GetProviderCache handler
+func (a *App) GetProviderCache(w http.ResponseWriter, r *http.Request) {
+	handler.GetProviderCache(a.Cache, w, r)
+}
+
+// PurgeProviderCache handler
+func (a *App) PurgeProviderCache(w http.ResponseWriter, r *http.Request) {
+	handler.PurgeProviderCache(a.Cache, w, r)
+}
+
+// GetResizedImage handler
+func (a *App) GetResizedImage(w http.ResponseWriter, r *http.Request) {
+	handler.GetResizedImage(a.DB, a.Storage, w, r)
 }
 
 // GetPlayer hanlder
@@ -292,11 +584,61 @@ func (a *App) GetPlayer(w http.ResponseWriter, r *http.Request) {
 	handler.GetPlayer(a.DB, w, r)
 }
 
+// Play handler
+func (a *App) Play(w http.ResponseWriter, r *http.Request) {
+	handler.Play(a.DB, w, r)
+}
+
+// PlayFile handler
+func (a *App) PlayFile(w http.ResponseWriter, r *http.Request) {
+	handler.PlayFile(a.DB, w, r)
+}
+
 // GetVideo hanlder
 func (a *App) GetVideo(w http.ResponseWriter, r *http.Request) {
 	handler.GetVideo(a.DB, w, r)
 }
 
+// GetShowPlayers handler
+func (a *App) GetShowPlayers(w http.ResponseWriter, r *http.Request) {
+	handler.GetShowPlayers(a.DB, w, r)
+}
+
+// CreatePlayer handler
+func (a *App) CreatePlayer(w http.ResponseWriter, r *http.Request) {
+	handler.CreatePlayer(a.DB, w, r)
+}
+
+// UpdatePlayer handler
+func (a *App) UpdatePlayer(w http.ResponseWriter, r *http.Request) {
+	handler.UpdatePlayer(a.DB, w, r)
+}
+
+// DeletePlayer handler
+func (a *App) DeletePlayer(w http.ResponseWriter, r *http.Request) {
+	handler.DeletePlayer(a.DB, w, r)
+}
+
+// ReorderPlayer handler
+func (a *App) ReorderPlayer(w http.ResponseWriter, r *http.Request) {
+	handler.ReorderPlayer(a.DB, w, r)
+}
+
+// UploadSubtitle handler
+func (a *App) UploadSubtitle(w http.ResponseWriter, r *http.Request) {
+	handler.UploadSubtitle(a.DB, a.Storage, w, r)
+}
+
+// UpdateSubtitle handler
+func (a *App) UpdateSubtitle(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateSubtitle(a.DB, a.Storage, w, r)
+}
+
+// DeleteSubtitle handler
+func (a *App) DeleteSubtitle(w http.ResponseWriter, r *http.Request) {
+	handler.DeleteSubtitle(a.DB, a.Storage, w, r)
+}
+
 // Concert
 
 // GetAllConcert handler
@@ -324,12 +666,118 @@ func (a *App) DeleteConcert(w http.ResponseWriter, r *http.Request) {
 	handler.DeleteConcert(a.DB, w, r)
 }
 
+// HOME
+
+// GetHome handler
+func (a *App) GetHome(w http.ResponseWriter, r *http.Request) {
+	handler.GetHome(a.DB, w, r)
+}
+
+// GetAllRail handler
+func (a *App) GetAllRail(w http.ResponseWriter, r *http.Request) {
+	handler.GetAllRail(a.DB, w, r)
+}
+
+// CreateRail handler
+func (a *App) CreateRail(w http.ResponseWriter, r *http.Request) {
+	handler.CreateRail(a.DB, w, r)
+}
+
+// GetRail handler
+func (a *App) GetRail(w http.ResponseWriter, r *http.Request) {
+	handler.GetRail(a.DB, w, r)
+}
+
+// UpdateRail handler
+func (a *App) UpdateRail(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateRail(a.DB, w, r)
+}
+
+// DeleteRail handler
+func (a *App) DeleteRail(w http.ResponseWriter, r *http.Request) {
+	handler.DeleteRail(a.DB, w, r)
+}
+
+// ReorderRail handler
+func (a *App) ReorderRail(w http.ResponseWriter, r *http.Request) {
+	handler.ReorderRail(a.DB, w, r)
+}
+
+// CURATED LIST
+
+// GetAllCuratedList handler
+func (a *App) GetAllCuratedList(w http.ResponseWriter, r *http.Request) {
+	handler.GetAllCuratedList(a.DB, w, r)
+}
+
+// CreateCuratedList handler
+func (a *App) CreateCuratedList(w http.ResponseWriter, r *http.Request) {
+	handler.CreateCuratedList(a.DB, w, r)
+}
+
+// GetCuratedList handler
+func (a *App) GetCuratedList(w http.ResponseWriter, r *http.Request) {
+	handler.GetCuratedList(a.DB, w, r)
+}
+
+// UpdateCuratedList handler
+func (a *App) UpdateCuratedList(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateCuratedList(a.DB, w, r)
+}
+
+// DeleteCuratedList handler
+func (a *App) DeleteCuratedList(w http.ResponseWriter, r *http.Request) {
+	handler.DeleteCuratedList(a.DB, w, r)
+}
+
+// ReorderCuratedList handler
+func (a *App) ReorderCuratedList(w http.ResponseWriter, r *http.Request) {
+	handler.ReorderCuratedList(a.DB, w, r)
+}
+
+// ANALYTICS
+
+// CreateEvents handler
+func (a *App) CreateEvents(w http.ResponseWriter, r *http.Request) {
+	handler.CreateEvents(a.DB, a.Recorder, w, r)
+}
+
+// GetTitleStats handler
+func (a *App) GetTitleStats(w http.ResponseWriter, r *http.Request) {
+	handler.GetTitleStats(a.DB, w, r)
+}
+
+// GetBrokenLinks handler
+func (a *App) GetBrokenLinks(w http.ResponseWriter, r *http.Request) {
+	handler.GetBrokenLinks(a.DB, w, r)
+}
+
+// ScanLibrary handler
+func (a *App) ScanLibrary(w http.ResponseWriter, r *http.Request) {
+	handler.ScanLibrary(a.DB, a.Providers, w, r)
+}
+
+// GetUnmatchedMedia handler
+func (a *App) GetUnmatchedMedia(w http.ResponseWriter, r *http.Request) {
+	handler.GetUnmatchedMedia(a.DB, w, r)
+}
+
+// MatchUnmatchedMedia handler
+func (a *App) MatchUnmatchedMedia(w http.ResponseWriter, r *http.Request) {
+	handler.MatchUnmatchedMedia(a.DB, w, r)
+}
+
+// GetTrending handler
+func (a *App) GetTrending(w http.ResponseWriter, r *http.Request) {
+	handler.GetTrending(a.DB, w, r)
+}
+
 // Run the app on it's router
 func (a *App) Run(host string) {
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "PUT", "POST", "DELETE"},
-		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "X-Profile-ID"},
 		// Enable Debugging for testing, consider disabling in production
 		// Debug: true,
 	})
@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache keeps values by key in memory, the least recently used is evicted when full.
+// With a directory values are also saved as files so they survive a restart
+type Cache struct {
+	size    int
+	dir     string
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	hits    int64
+	misses  int64
+}
+
+// Stats of the cache since it started
+type Stats struct {
+	Entries int    `json:"entries"`
+	Size    int    `json:"size"`
+	Dir     string `json:"dir"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+}
+
+// entry is also the file format on disk
+type entry struct {
+	Key     string    `json:"key"`
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// New create cache of size entries in memory, empty dir keeps them only in memory
+func New(size int, dir string) *Cache {
+	if size <= 0 {
+		size = 1000
+	}
+	return &Cache{size: size, dir: dir, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// FromEnv create cache of PROVIDER_CACHE_SIZE entries, 1000 by default,
+// saved to PROVIDER_CACHE_DIR when it is set
+func FromEnv() *Cache {
+	size, _ := strconv.Atoi(os.Getenv("PROVIDER_CACHE_SIZE"))
+	return New(size, os.Getenv("PROVIDER_CACHE_DIR"))
+}
+
+// Get returns value of key unless it is missing or expired
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry)
+		if time.Now().Before(e.Expires) {
+			c.order.MoveToFront(element)
+			c.hits++
+			return e.Value, true
+		}
+		c.remove(element)
+	}
+
+	// Not in memory, maybe saved before a restart or evicted
+	if e := c.read(key); e != nil {
+		c.add(e)
+		c.hits++
+		return e.Value, true
+	}
+	c.misses++
+	return nil, false
+}
+
+// Set keeps value of key for ttl, zero or negative ttl doesn't keep it
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	e := &entry{Key: key, Value: value, Expires: time.Now().Add(ttl)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.add(e)
+	c.write(e)
+}
+
+// Purge removes every entry of key starting with prefix, empty prefix removes all.
+// It returns how many entries were removed
+func (c *Cache) Purge(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := map[string]bool{}
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+			purged[key] = true
+		}
+	}
+
+	if len(c.dir) != 0 {
+		files, _ := filepath.Glob(filepath.Join(c.dir, "*.json"))
+		for _, file := range files {
+			e := readFile(file)
+			if e == nil || strings.HasPrefix(e.Key, prefix) {
+				os.Remove(file)
+				if e != nil {
+					purged[e.Key] = true
+				}
+			}
+		}
+	}
+	return len(purged)
+}
+
+// Stats returns the number of entries in memory, hits and misses
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Entries: c.order.Len(), Size: c.size, Dir: c.dir, Hits: c.hits, Misses: c.misses}
+}
+
+// add puts entry in front, the least recently used is evicted from memory but kept on disk
+func (c *Cache) add(e *entry) {
+	c.entries[e.Key] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).Key)
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	key := element.Value.(*entry).Key
+	c.order.Remove(element)
+	delete(c.entries, key)
+	if len(c.dir) != 0 {
+		os.Remove(c.file(key))
+	}
+}
+
+// read returns the saved entry of key, expired one is removed
+func (c *Cache) read(key string) *entry {
+	if len(c.dir) == 0 {
+		return nil
+	}
+	e := readFile(c.file(key))
+	if e == nil || e.Key != key {
+		return nil
+	}
+	if !time.Now().Before(e.Expires) {
+		os.Remove(c.file(key))
+		return nil
+	}
+	return e
+}
+
+// write saves entry to a temporary file first so a reader never sees half of it
+func (c *Cache) write(e *entry) {
+	if len(c.dir) == 0 {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.file(e.Key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// file is named by hash of the key since key may have any character
+func (c *Cache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func readFile(file string) *entry {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	e := entry{}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+	return &e
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status of a cached call, sent in the X-Cache header
+const (
+	Hit  = "HIT"
+	Miss = "MISS"
+)
+
+// Header tells whether the response came from the cache
+const Header = "X-Cache"
+
+// TTLs is how long a value is kept by endpoint, ex: search, movie, images
+type TTLs map[string]time.Duration
+
+// DefaultTTLs keep search results shorter since new titles show up there first
+var DefaultTTLs = TTLs{
+	"search":       6 * time.Hour,
+	"movie":        24 * time.Hour,
+	"tv":           24 * time.Hour,
+	"season":       24 * time.Hour,
+	"episode":      24 * time.Hour,
+	"images":       24 * time.Hour,
+	"person":       24 * time.Hour,
+	"image-search": 6 * time.Hour,
+}
+
+// TTLsFromEnv returns DefaultTTLs changed by PROVIDER_CACHE_TTL, ex: search:1h,movie:72h.
+// A zero TTL turns caching of the endpoint off
+func TTLsFromEnv() TTLs {
+	ttls := TTLs{}
+	for endpoint, ttl := range DefaultTTLs {
+		ttls[endpoint] = ttl
+	}
+	for _, item := range strings.Split(os.Getenv("PROVIDER_CACHE_TTL"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if ttl, err := time.ParseDuration(parts[1]); err == nil && ttl >= 0 {
+			ttls[parts[0]] = ttl
+		}
+	}
+	return ttls
+}
+
+// Load decodes the cached value of key into v. On a miss fetch is called and its result is cached
+// for the TTL of endpoint, error is never cached. It returns Hit or Miss
+func (c *Cache) Load(key string, endpoint string, ttls TTLs, v interface{}, fetch func() (interface{}, error)) (string, error) {
+	if data, ok := c.Get(key); ok && json.Unmarshal(data, v) == nil {
+		return Hit, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return Miss, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Miss, err
+	}
+	c.Set(key, data, ttls[endpoint])
+	return Miss, json.Unmarshal(data, v)
+}
+
+// Tracker sets the X-Cache header of a response from the status of every cached call made for it,
+// HIT only when all of them hit
+type Tracker struct {
+	header http.Header
+	mu     sync.Mutex
+}
+
+// NewTracker create tracker of the response header
+func NewTracker(header http.Header) *Tracker {
+	return &Tracker{header: header}
+}
+
+// Record adds status of a call, nil tracker records nothing
+func (t *Tracker) Record(status string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if current := t.header.Get(Header); len(current) == 0 || status == Miss {
+		t.header.Set(Header, status)
+	}
+}
@@ -0,0 +1,157 @@
+package gallery
+
+import (
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+// attachmentOwners are tables which can have image attachments
+var attachmentOwners = []string{"movies", "tvs", "tv_seasons", "tv_episodes", "concerts", "people"}
+
+// legacyRoles are role of images in imageJoinTables
+var legacyRoles = map[string]string{
+	"movies_posters":   model.ImagePoster,
+	"movies_banners":   model.ImageBanner,
+	"tv_posters":       model.ImagePoster,
+	"tv_banners":       model.ImageBanner,
+	"concerts_banners": model.ImageBanner,
+}
+
+// AttachLegacyImages copies posters and banners of the many2many tables to image attachments,
+// the lowest image id becomes primary. Owner already having attachments of the role is skipped
+func AttachLegacyImages(db *gorm.DB) (int, error) {
+	attached := 0
+	for table, owner := range imageJoinTables {
+		ownerTable := imageOwners[owner]
+		role := legacyRoles[table]
+
+		rows := []struct {
+			OwnerID int
+			ImageID uint
+		}{}
+		if err := db.Table(table).
+			Select(owner+" AS owner_id, image_id").
+			Where(owner+" NOT IN (SELECT owner_id FROM image_attachments WHERE owner_type = ? AND role = ?)", ownerTable, role).
+			Order(owner + ", image_id").
+			Scan(&rows).Error; err != nil {
+			return attached, err
+		}
+
+		position, lastOwner := 0, 0
+		for _, row := range rows {
+			if row.OwnerID != lastOwner {
+				position, lastOwner = 0, row.OwnerID
+			}
+			attachment := model.ImageAttachment{
+				ImageID:   row.ImageID,
+				OwnerID:   row.OwnerID,
+				OwnerType: ownerTable,
+				Role:      role,
+				Position:  position,
+				IsPrimary: position == 0,
+			}
+			if err := db.Create(&attachment).Error; err != nil {
+				return attached, err
+			}
+			position++
+			attached++
+		}
+	}
+	return attached, nil
+}
+
+// SyncLegacyImages makes posters and banners attachments of the owner follow its many2many tables,
+// which the movie and concert handlers still write. Attachment of an image still in the table keeps
+// its position and primary, new image goes last
+func SyncLegacyImages(db *gorm.DB, ownerType string, ownerID int) error {
+	for table, owner := range imageJoinTables {
+		if imageOwners[owner] != ownerType {
+			continue
+		}
+		role := legacyRoles[table]
+
+		ids := []uint{}
+		if err := db.Table(table).Where(owner+" = ?", ownerID).Order("image_id").Pluck("image_id", &ids).Error; err != nil {
+			return err
+		}
+		joined := map[uint]bool{}
+		for _, id := range ids {
+			joined[id] = true
+		}
+
+		attachments := []model.ImageAttachment{}
+		if err := db.Where("owner_id = ? AND owner_type = ? AND role = ?", ownerID, ownerType, role).
+			Order("position").
+			Find(&attachments).Error; err != nil {
+			return err
+		}
+
+		kept := []model.ImageAttachment{}
+		attached := map[uint]bool{}
+		for _, attachment := range attachments {
+			if !joined[attachment.ImageID] || attached[attachment.ImageID] {
+				if err := db.Delete(&attachment).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			attached[attachment.ImageID] = true
+			kept = append(kept, attachment)
+		}
+
+		hasPrimary := false
+		for _, attachment := range kept {
+			hasPrimary = hasPrimary || attachment.IsPrimary
+		}
+		if !hasPrimary && len(kept) > 0 {
+			if err := db.Model(&kept[0]).Update("is_primary", true).Error; err != nil {
+				return err
+			}
+			hasPrimary = true
+		}
+
+		position := len(kept)
+		if len(kept) > 0 {
+			position = kept[len(kept)-1].Position + 1
+		}
+		for _, id := range ids {
+			if attached[id] {
+				continue
+			}
+			attachment := model.ImageAttachment{
+				ImageID:   id,
+				OwnerID:   ownerID,
+				OwnerType: ownerType,
+				Role:      role,
+				Position:  position,
+				IsPrimary: !hasPrimary,
+			}
+			if err := db.Create(&attachment).Error; err != nil {
+				return err
+			}
+			position++
+			hasPrimary = true
+		}
+	}
+	return nil
+}
+
+// WriteLegacyImage adds or removes the many2many row of a poster or banner attachment,
+// so image attached through its role also shows in posters and banners of the owner
+func WriteLegacyImage(db *gorm.DB, attachment model.ImageAttachment, attached bool) error {
+	for table, owner := range imageJoinTables {
+		if imageOwners[owner] != attachment.OwnerType || legacyRoles[table] != attachment.Role {
+			continue
+		}
+
+		if !attached {
+			return db.Exec("DELETE FROM "+table+" WHERE "+owner+" = ? AND image_id = ?", attachment.OwnerID, attachment.ImageID).Error
+		}
+		var count int
+		if err := db.Table(table).Where(owner+" = ? AND image_id = ?", attachment.OwnerID, attachment.ImageID).Count(&count).Error; err != nil || count > 0 {
+			return err
+		}
+		return db.Exec("INSERT INTO "+table+" ("+owner+", image_id) VALUES (?, ?)", attachment.OwnerID, attachment.ImageID).Error
+	}
+	return nil
+}
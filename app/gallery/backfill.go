@@ -0,0 +1,92 @@
+package gallery
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/imaging"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// maxBackfillAttempts stop retrying image whose original can't be read or decoded
+const maxBackfillAttempts = 3
+
+// Backfill computes placeholder and perceptual hash of images ingested before they existed.
+// Image which can't be read is retried on the next runs, up to maxBackfillAttempts
+func Backfill(db *gorm.DB, store storage.Storage, batch int) (int, error) {
+	filled := 0
+	lastID := uint(0)
+	for {
+		images := []model.Image{}
+		if err := db.
+			Where("id > ? AND (blur_hash IS NULL OR blur_hash = '') AND COALESCE(backfill_attempts, 0) < ?", lastID, maxBackfillAttempts).
+			Order("id").
+			Limit(batch).
+			Find(&images).Error; err != nil {
+			return filled, err
+		}
+		if len(images) == 0 {
+			return filled, nil
+		}
+
+		for i := range images {
+			image := &images[i]
+			lastID = image.ID
+
+			img, err := decodeOriginal(store, image)
+			if err != nil {
+				if err := db.Model(image).UpdateColumns(map[string]interface{}{
+					"backfill_attempts": gorm.Expr("COALESCE(backfill_attempts, 0) + 1"),
+					"backfill_error":    err.Error(),
+				}).Error; err != nil {
+					return filled, err
+				}
+				continue
+			}
+
+			placeholder := imaging.NewPlaceholder(img)
+			columns := map[string]interface{}{
+				"blur_hash":      placeholder.BlurHash,
+				"dominant_color": placeholder.DominantColor,
+				"average_color":  placeholder.AverageColor,
+			}
+			if image.PHash == 0 {
+				columns["phash"] = imaging.PerceptualHash(img)
+			}
+			if err := db.Model(image).UpdateColumns(columns).Error; err != nil {
+				return filled, err
+			}
+			filled++
+		}
+	}
+}
+
+// decodeOriginal reads and decodes the original file of the row
+func decodeOriginal(store storage.Storage, row *model.Image) (image.Image, error) {
+	data, err := original(store, row)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := imaging.Decode(data)
+	return img, err
+}
+
+// RunBackfill backfills images periodically
+func RunBackfill(db *gorm.DB, store storage.Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		filled, err := Backfill(db, store, 100)
+		if err != nil {
+			log.Println("gallery: backfill", err)
+			continue
+		}
+		if filled > 0 {
+			log.Printf("gallery: backfilled %d images", filled)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package gallery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/condrowiyono/ruangtengah-api/app/imaging"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+// imageJoinTables are many2many tables of images, by their owner column
+var imageJoinTables = map[string]string{
+	"movies_posters":   "movie_id",
+	"movies_banners":   "movie_id",
+	"tv_posters":       "tv_id",
+	"tv_banners":       "tv_id",
+	"concerts_banners": "concert_id",
+}
+
+// imageColumns are columns pointing to a single image, by their table
+var imageColumns = map[string]string{
+	"curated_lists": "cover_id",
+	"profiles":      "avatar_id",
+}
+
+// FindDuplicate returns the image uploaded with exactly the same content, nil if none
+func FindDuplicate(db *gorm.DB, data []byte) *model.Image {
+	image := model.Image{}
+	if err := db.Where("sha256 = ?", contentHash(data)).First(&image).Error; err != nil {
+		return nil
+	}
+	return &image
+}
+
+// Clusters groups images whose perceptual hashes differ by at most distance bits.
+// Only group of two or more is returned
+func Clusters(db *gorm.DB, distance int) ([][]model.Image, error) {
+	images := []model.Image{}
+	if err := db.Where("phash <> 0").Order("id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+
+	// Union find of similar pairs
+	parent := make([]int, len(images))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			if imaging.HammingDistance(images[i].PHash, images[j].PHash) <= distance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := map[int][]model.Image{}
+	order := []int{}
+	for i, image := range images {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], image)
+	}
+
+	clusters := [][]model.Image{}
+	for _, root := range order {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+	return clusters, nil
+}
+
+// Merge re-points every reference of the images to keepID, then deletes them.
+// Owner already having keepID, in the same role for attachments, only loses the duplicate
+func Merge(db *gorm.DB, keepID uint, ids []uint) error {
+	merged := []uint{}
+	for _, id := range ids {
+		if id != keepID {
+			merged = append(merged, id)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	tx := db.Begin()
+	if err := tx.Model(&model.ImageAttachment{}).Where("image_id IN (?)", merged).UpdateColumn("image_id", keepID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := dedupAttachments(tx, keepID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for table, owner := range imageJoinTables {
+		if err := tx.Exec(fmt.Sprintf(
+			`INSERT INTO %[1]s (%[2]s, image_id)
+			SELECT DISTINCT %[2]s, ? FROM %[1]s
+			WHERE image_id IN (?) AND %[2]s NOT IN (SELECT %[2]s FROM (SELECT %[2]s FROM %[1]s WHERE image_id = ?) AS kept)`,
+			table, owner), keepID, merged, keepID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE image_id IN (?)", table), merged).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for table, column := range imageColumns {
+		if err := tx.Table(table).Where(column+" IN (?)", merged).UpdateColumn(column, keepID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Where("id IN (?)", merged).Delete(&model.Image{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// dedupAttachments keeps one attachment of the image per owner and role, the primary or the first one
+func dedupAttachments(db *gorm.DB, imageID uint) error {
+	attachments := []model.ImageAttachment{}
+	if err := db.Where("image_id = ?", imageID).Order("is_primary DESC, position, id").Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	kept := map[string]bool{}
+	duplicates := []uint{}
+	for _, attachment := range attachments {
+		key := fmt.Sprintf("%s:%d:%s", attachment.OwnerType, attachment.OwnerID, attachment.Role)
+		if kept[key] {
+			duplicates = append(duplicates, attachment.ID)
+			continue
+		}
+		kept[key] = true
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	return db.Where("id IN (?)", duplicates).Delete(&model.ImageAttachment{}).Error
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package gallery
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MaxDownloadSize is the same limit as upload
+const MaxDownloadSize = 10 << 20
+
+var fetchClient = &http.Client{Timeout: 30 * time.Second}
+
+// DownloadError is returned when remote image can not be fetched
+type DownloadError struct {
+	URL    string
+	Reason string
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("download %s: %s", e.URL, e.Reason)
+}
+
+// Fetch downloads a remote image, response bigger than MaxDownloadSize is refused
+func Fetch(rawURL string) ([]byte, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+		return nil, &DownloadError{URL: rawURL, Reason: "invalid url"}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, &DownloadError{URL: rawURL, Reason: err.Error()}
+	}
+	// Some host refuses client without user agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; ruangtengah-api)")
+	req.Header.Set("Accept", "image/*")
+
+	res, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, &DownloadError{URL: rawURL, Reason: err.Error()}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &DownloadError{URL: rawURL, Reason: res.Status}
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, MaxDownloadSize+1))
+	if err != nil {
+		return nil, &DownloadError{URL: rawURL, Reason: err.Error()}
+	}
+	if len(data) > MaxDownloadSize {
+		return nil, &DownloadError{URL: rawURL, Reason: fmt.Sprintf("image is larger than %d bytes", MaxDownloadSize)}
+	}
+	return data, nil
+}
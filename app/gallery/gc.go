@@ -0,0 +1,330 @@
+package gallery
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// imageOwners are tables owning images through imageJoinTables, by their owner column
+var imageOwners = map[string]string{
+	"movie_id":   "movies",
+	"tv_id":      "tvs",
+	"concert_id": "concerts",
+}
+
+// pictureTables keep the picture as URL instead of image id
+var pictureTables = []string{"people", "artists"}
+
+// legacyUploadDir is followed by the key in URL of the local storage, ex: BASE_URL/uploads/upload-123.png
+const legacyUploadDir = "/uploads/"
+
+// legacyUploadPath is in the URL of files uploaded before images/
+const legacyUploadPath = legacyUploadDir + "upload-"
+
+// filePrefixes are storage keys of image files, upload- is the root of files uploaded before images/
+var filePrefixes = []string{imageDir + "/", "upload-"}
+
+// GCReport lists images and files which are not used anymore
+type GCReport struct {
+	DryRun bool             `json:"dry_run"`
+	Grace  string           `json:"grace"`
+	Images []model.Image    `json:"images"`
+	Files  []storage.Object `json:"files"`
+}
+
+// GraceFromEnv is how long an unused image is kept, IMAGE_GC_GRACE like 72h, a week by default
+func GraceFromEnv() time.Duration {
+	grace, err := time.ParseDuration(os.Getenv("IMAGE_GC_GRACE"))
+	if err != nil || grace < 0 {
+		return 7 * 24 * time.Hour
+	}
+	return grace
+}
+
+// Collect finds images not attached to any live owner nor used by curated list, profile, person or artist picture
+// and files of the storage without image, unused longer than grace.
+// They are deleted unless dryRun, soft deleted images are purged as well.
+// Image is unused since it was soft deleted or since the first run finding it unused, which is recorded
+// even on dry run, so grace counts from when it was detached or replaced rather than uploaded
+func Collect(db *gorm.DB, store storage.Storage, grace time.Duration, dryRun bool) (*GCReport, error) {
+	report := &GCReport{DryRun: dryRun, Grace: grace.String(), Images: []model.Image{}, Files: []storage.Object{}}
+	now := time.Now()
+	cutoff := now.Add(-grace)
+
+	used, err := usedImages(db)
+	if err != nil {
+		return nil, err
+	}
+	pictures, err := usedPictures(db)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []model.Image{}
+	if err := db.Unscoped().Order("id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+
+	// Files of every row are known, files of orphan rows go with the row.
+	// A file whose key ends a URL of any row or picture is kept too, so a changed BASE_URL or driver never orphans it
+	known := map[string]bool{}
+	unused, reused := []uint{}, []uint{}
+	for _, image := range images {
+		for _, key := range imageKeys(store, image) {
+			known[key] = true
+		}
+		for _, url := range imageURLs(image) {
+			addURLKeys(known, url)
+		}
+
+		if used[image.ID] || isPicture(pictures, image) {
+			if image.UnusedSince != nil {
+				reused = append(reused, image.ID)
+			}
+			continue
+		}
+
+		unusedSince := now
+		if image.UnusedSince != nil {
+			unusedSince = *image.UnusedSince
+		} else {
+			unused = append(unused, image.ID)
+		}
+		if image.DeletedAt != nil && image.DeletedAt.Before(unusedSince) {
+			unusedSince = *image.DeletedAt
+		}
+		if unusedSince.Before(cutoff) {
+			report.Images = append(report.Images, image)
+		}
+	}
+
+	for url := range pictures {
+		addURLKeys(known, url)
+	}
+
+	if err := markUnused(db, unused, &now); err != nil {
+		return nil, err
+	}
+	if err := markUnused(db, reused, nil); err != nil {
+		return nil, err
+	}
+
+	// Storage which can't list its files only loses the rows
+	if lister, ok := store.(storage.Lister); ok {
+		for _, prefix := range filePrefixes {
+			objects, err := lister.List(prefix)
+			if err != nil {
+				return nil, err
+			}
+			for _, object := range objects {
+				if !known[object.Key] && object.ModTime.Before(cutoff) {
+					report.Files = append(report.Files, object)
+				}
+			}
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, purge(db, store, report)
+}
+
+// RunGC collects unused images periodically
+func RunGC(db *gorm.DB, store storage.Storage, grace time.Duration, dryRun bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		report, err := Collect(db, store, grace, dryRun)
+		if err != nil {
+			log.Println("gallery: gc", err)
+			continue
+		}
+		if len(report.Images) == 0 && len(report.Files) == 0 {
+			continue
+		}
+		if dryRun {
+			log.Printf("gallery: gc found %d unused images and %d orphan files (dry run)", len(report.Images), len(report.Files))
+		} else {
+			log.Printf("gallery: gc deleted %d unused images and %d orphan files", len(report.Images), len(report.Files))
+		}
+	}
+}
+
+// purge deletes the rows first, so a failure leaves files without row which the next run picks up
+func purge(db *gorm.DB, store storage.Storage, report *GCReport) error {
+	ids := []uint{}
+	for _, image := range report.Images {
+		ids = append(ids, image.ID)
+	}
+
+	if len(ids) > 0 {
+		tx := db.Begin()
+		// Join rows and attachments of deleted owners
+		for table := range imageJoinTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE image_id IN (?)", ids).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("image_id IN (?)", ids).Delete(&model.ImageAttachment{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN (?)", ids).Delete(&model.Image{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+	}
+
+	for _, image := range report.Images {
+		for _, key := range imageKeys(store, image) {
+			if err := store.Delete(key); err != nil {
+				log.Println("gallery: gc", key, err)
+			}
+		}
+	}
+	for _, object := range report.Files {
+		if err := store.Delete(object.Key); err != nil {
+			log.Println("gallery: gc", object.Key, err)
+		}
+	}
+	return nil
+}
+
+// usedImages returns id of images referenced by a live owner
+func usedImages(db *gorm.DB) (map[uint]bool, error) {
+	used := map[uint]bool{}
+	for table, owner := range imageJoinTables {
+		ids := []uint{}
+		if err := db.Table(table).
+			Joins("JOIN "+imageOwners[owner]+" ON "+imageOwners[owner]+".id = "+table+"."+owner).
+			Where(imageOwners[owner]+".deleted_at IS NULL").
+			Pluck(table+".image_id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			used[id] = true
+		}
+	}
+	for _, owner := range attachmentOwners {
+		ids := []uint{}
+		if err := db.Table("image_attachments").
+			Joins("JOIN "+owner+" ON "+owner+".id = image_attachments.owner_id AND image_attachments.owner_type = ?", owner).
+			Where("image_attachments.deleted_at IS NULL AND "+owner+".deleted_at IS NULL").
+			Pluck("image_attachments.image_id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			used[id] = true
+		}
+	}
+	for table, column := range imageColumns {
+		ids := []uint{}
+		if err := db.Table(table).Where("deleted_at IS NULL").Pluck(column, &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			used[id] = true
+		}
+	}
+	return used, nil
+}
+
+// markUnused sets when the images were found unused, nil clears it
+func markUnused(db *gorm.DB, ids []uint, since *time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Unscoped().Model(&model.Image{}).Where("id IN (?)", ids).UpdateColumn("unused_since", since).Error
+}
+
+// usedPictures returns picture URL of live people and artists
+func usedPictures(db *gorm.DB) (map[string]bool, error) {
+	pictures := map[string]bool{}
+	for _, table := range pictureTables {
+		urls := []string{}
+		if err := db.Table(table).Where("deleted_at IS NULL AND picture <> ''").Pluck("picture", &urls).Error; err != nil {
+			return nil, err
+		}
+		for _, url := range urls {
+			pictures[url] = true
+		}
+	}
+	return pictures, nil
+}
+
+// isPicture tells whether the image or one of its renditions is used as picture
+func isPicture(pictures map[string]bool, image model.Image) bool {
+	if pictures[image.Path] {
+		return true
+	}
+	for _, url := range image.Renditions {
+		if pictures[url] {
+			return true
+		}
+	}
+	return false
+}
+
+// imageKeys returns storage key of every file of the image.
+// Image uploaded before the storage has its keys only in the URL, under the storage URL
+// or after /uploads/ of the local storage even when BASE_URL has changed since
+func imageKeys(store storage.Storage, image model.Image) []string {
+	unique := map[string]bool{}
+	for _, key := range image.Keys {
+		unique[key] = true
+	}
+
+	for _, url := range imageURLs(image) {
+		if key := urlKey(store, url); len(key) != 0 {
+			unique[key] = true
+		}
+	}
+
+	keys := []string{}
+	for key := range unique {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// urlKey returns storage key of a file URL of the storage, empty for other URL
+func urlKey(store storage.Storage, url string) string {
+	prefix := store.URL("")
+	if len(url) > len(prefix) && strings.HasPrefix(url, prefix) {
+		return strings.TrimPrefix(url, prefix)
+	}
+	if i := strings.Index(url, legacyUploadDir); i >= 0 && len(url) > i+len(legacyUploadDir) {
+		return url[i+len(legacyUploadDir):]
+	}
+	return ""
+}
+
+// addURLKeys adds every path ending the URL as a key, ex: uploads/upload-1.png and upload-1.png
+func addURLKeys(keys map[string]bool, url string) {
+	for i, c := range url {
+		if c == '/' {
+			keys[url[i+1:]] = true
+		}
+	}
+}
+
+// imageURLs returns URL of the image and its renditions
+func imageURLs(image model.Image) []string {
+	urls := []string{image.Path}
+	for _, url := range image.Renditions {
+		urls = append(urls, url)
+	}
+	return urls
+}
@@ -0,0 +1,76 @@
+package gallery
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/condrowiyono/ruangtengah-api/app/imaging"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// imageDir is the storage prefix of image files
+const imageDir = "images"
+
+// Ingest validates the image data, stores the original with its renditions and saves the row.
+// File fields of the image (path, size, renditions, hashes, ...) are overwritten
+func Ingest(db *gorm.DB, store storage.Storage, data []byte, image *model.Image) error {
+	result, err := imaging.Process(data, imaging.RenditionsFromEnv())
+	if err != nil {
+		return err
+	}
+
+	urls, keys, err := storeResult(store, result)
+	if err != nil {
+		return err
+	}
+
+	image.Path = urls[imaging.OriginalRendition]
+	image.Width = result.Width
+	image.Height = result.Height
+	image.MimeType = result.Outputs[0].MimeType
+	image.Renditions = urls
+	image.Keys = keys
+	image.SHA256 = contentHash(data)
+	image.PHash = result.PHash
+	image.BlurHash = result.BlurHash
+	image.DominantColor = result.DominantColor
+	image.AverageColor = result.AverageColor
+
+	if err := db.Save(image).Error; err != nil {
+		deleteKeys(store, keys)
+		return err
+	}
+	return nil
+}
+
+// storeResult puts every output to the storage and returns their URL and key by rendition name.
+// Renditions share the original key, ex: images/upload-123.jpg and images/upload-123-thumbnail.jpg
+func storeResult(store storage.Storage, result *imaging.Result) (model.Renditions, model.Renditions, error) {
+	original := result.Outputs[0]
+	base := strings.TrimSuffix(storage.NewKey(imageDir, "upload", original.Ext), original.Ext)
+
+	urls := model.Renditions{}
+	keys := model.Renditions{}
+	for _, output := range result.Outputs {
+		key := base + output.Ext
+		if output.Name != imaging.OriginalRendition {
+			key = base + "-" + output.Name + output.Ext
+		}
+
+		if err := store.Put(key, bytes.NewReader(output.Data), output.MimeType); err != nil {
+			deleteKeys(store, keys)
+			return nil, nil, err
+		}
+		urls[output.Name] = store.URL(key)
+		keys[output.Name] = key
+	}
+	return urls, keys, nil
+}
+
+func deleteKeys(store storage.Storage, keys model.Renditions) {
+	for _, key := range keys {
+		store.Delete(key)
+	}
+}
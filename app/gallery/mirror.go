@@ -0,0 +1,72 @@
+package gallery
+
+import (
+	"log"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// maxMirrorAttempts stop retrying image whose source is gone
+const maxMirrorAttempts = 3
+
+// Mirror copies up to batch hotlinked images into the storage and rewrites their path.
+// Files already served by the storage, including uploads of before images/, are never mirrored.
+// Returns how many images were mirrored
+func Mirror(db *gorm.DB, store storage.Storage, batch int) (int, error) {
+	ownURL := store.URL("")
+	images := []model.Image{}
+	if err := db.
+		Where("(storage_keys IS NULL OR storage_keys = '') AND path LIKE 'http%' AND COALESCE(mirror_attempts, 0) < ?", maxMirrorAttempts).
+		Where("SUBSTR(path, 1, ?) <> ? AND path NOT LIKE ?", len(ownURL), ownURL, "%"+legacyUploadPath+"%").
+		Order("id").
+		Limit(batch).
+		Find(&images).Error; err != nil {
+		return 0, err
+	}
+
+	mirrored := 0
+	for i := range images {
+		image := &images[i]
+		origin := image.Path
+
+		data, err := Fetch(origin)
+		if err == nil {
+			// Same content is already stored, the hotlinked row is merged into it
+			if duplicate := FindDuplicate(db, data); duplicate != nil {
+				err = Merge(db, duplicate.ID, []uint{image.ID})
+			} else {
+				image.OriginURL = origin
+				err = Ingest(db, store, data, image)
+			}
+		}
+		if err != nil {
+			db.Model(image).UpdateColumns(map[string]interface{}{
+				"mirror_attempts": gorm.Expr("COALESCE(mirror_attempts, 0) + 1"),
+				"mirror_error":    err.Error(),
+			})
+			continue
+		}
+		mirrored++
+	}
+	return mirrored, nil
+}
+
+// RunMirror mirrors hotlinked images periodically, a batch at a time
+func RunMirror(db *gorm.DB, store storage.Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		mirrored, err := Mirror(db, store, 100)
+		if err != nil {
+			log.Println("gallery: mirror", err)
+			continue
+		}
+		if mirrored > 0 {
+			log.Printf("gallery: mirrored %d images", mirrored)
+		}
+	}
+}
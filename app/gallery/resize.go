@@ -0,0 +1,170 @@
+package gallery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/condrowiyono/ruangtengah-api/app/imaging"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
+)
+
+// ResizeOptions of on the fly resized image
+type ResizeOptions struct {
+	Width  int
+	Height int
+	Fit    string
+	Format string
+}
+
+// ResizeSizes are the width or height served, other size is rounded up to the next one
+// so requests can't fill the cache with every size
+var ResizeSizes = []int{92, 154, 185, 300, 342, 500, 780, 1280, imaging.MaxDimension}
+
+// Normalize snaps the size to ResizeSizes and sets the default fit and format,
+// so options giving the same image share the cache key
+func (o ResizeOptions) Normalize() (ResizeOptions, error) {
+	if o.Width < 0 || o.Height < 0 || o.Width > imaging.MaxDimension || o.Height > imaging.MaxDimension {
+		return o, imaging.ErrInvalidTransform
+	}
+	// The longer side is snapped and the other follows the requested aspect ratio
+	switch {
+	case o.Width >= o.Height && o.Height != 0:
+		width := snapSize(o.Width)
+		o.Width, o.Height = width, maxInt(1, (o.Height*width+o.Width/2)/o.Width)
+	case o.Height > o.Width && o.Width != 0:
+		height := snapSize(o.Height)
+		o.Width, o.Height = maxInt(1, (o.Width*height+o.Height/2)/o.Height), height
+	default:
+		o.Width = snapSize(o.Width)
+		o.Height = snapSize(o.Height)
+	}
+
+	switch o.Fit {
+	case "":
+		o.Fit = imaging.FitCover
+	case imaging.FitCover, imaging.FitContain, imaging.FitFill:
+	default:
+		return o, imaging.ErrInvalidTransform
+	}
+
+	// Anything else, including webp, is chosen by the image
+	if o.Format != imaging.FormatJPEG && o.Format != imaging.FormatPNG {
+		o.Format = ""
+	}
+	return o, nil
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// snapSize rounds size up to the next of ResizeSizes, zero follows the aspect ratio and is kept
+func snapSize(size int) int {
+	if size == 0 {
+		return 0
+	}
+	for _, v := range ResizeSizes {
+		if size <= v {
+			return v
+		}
+	}
+	return ResizeSizes[len(ResizeSizes)-1]
+}
+
+// Resized returns cached file of the resized image and its cache key, the file is made on the first request.
+// Key includes the image path so a replaced image gets a new file
+func Resized(store storage.Storage, image *model.Image, options ResizeOptions) (string, string, error) {
+	options, err := options.Normalize()
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%d|%d|%s|%s",
+		image.ID, image.Path, options.Width, options.Height, options.Fit, options.Format)))
+	key := hex.EncodeToString(hash[:])
+	filename := filepath.Join(resizeCacheDir(), key[:2], key)
+
+	if _, err := os.Stat(filename); err == nil {
+		return filename, key, nil
+	}
+
+	data, err := original(store, image)
+	if err != nil {
+		return "", "", err
+	}
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		return "", "", err
+	}
+	img, err = imaging.Transform(img, options.Width, options.Height, options.Fit)
+	if err != nil {
+		return "", "", err
+	}
+	resized, _, err := imaging.Encode(img, options.Format)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := writeCache(filename, resized); err != nil {
+		return "", "", err
+	}
+	return filename, key, nil
+}
+
+// original reads the original file from storage, legacy upload by the key in its URL, hotlinked image is downloaded
+func original(store storage.Storage, image *model.Image) ([]byte, error) {
+	key, ok := image.Keys[imaging.OriginalRendition]
+	if !ok {
+		// Uploaded before the storage, its key is only in the URL
+		key = urlKey(store, image.Path)
+	}
+	if len(key) == 0 {
+		return Fetch(image.Path)
+	}
+
+	file, err := store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// writeCache writes through a temporary file so concurrent request never reads a partial file
+func writeCache(filename string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+	return os.Rename(tempFile.Name(), filename)
+}
+
+// resizeCacheDir is IMAGE_CACHE_DIR, or cache/images by default
+func resizeCacheDir() string {
+	dir := os.Getenv("IMAGE_CACHE_DIR")
+	if len(dir) == 0 {
+		dir = "cache/images"
+	}
+	return dir
+}
@@ -0,0 +1,39 @@
+package gallery
+
+import (
+	"testing"
+
+	"github.com/condrowiyono/ruangtengah-api/app/imaging"
+)
+
+func TestResizeOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		options ResizeOptions
+		want    ResizeOptions
+	}{
+		{ResizeOptions{Width: 310}, ResizeOptions{Width: 342, Fit: imaging.FitCover}},
+		{ResizeOptions{Height: 2000}, ResizeOptions{Height: 2000, Fit: imaging.FitCover}},
+		{ResizeOptions{Width: 300, Height: 450}, ResizeOptions{Width: 333, Height: 500, Fit: imaging.FitCover}},
+		{ResizeOptions{Width: 1920, Height: 1080, Fit: imaging.FitFill}, ResizeOptions{Width: 2000, Height: 1125, Fit: imaging.FitFill}},
+		{ResizeOptions{Width: 500, Height: 500}, ResizeOptions{Width: 500, Height: 500, Fit: imaging.FitCover}},
+		{ResizeOptions{Width: 2000, Height: 1}, ResizeOptions{Width: 2000, Height: 1, Fit: imaging.FitCover}},
+		{ResizeOptions{Width: 92, Format: "webp"}, ResizeOptions{Width: 92, Fit: imaging.FitCover}},
+		{ResizeOptions{Width: 92, Format: imaging.FormatPNG}, ResizeOptions{Width: 92, Fit: imaging.FitCover, Format: imaging.FormatPNG}},
+	}
+	for _, test := range tests {
+		got, err := test.options.Normalize()
+		if err != nil {
+			t.Errorf("%+v: %v", test.options, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%+v normalized to %+v, want %+v", test.options, got, test.want)
+		}
+	}
+
+	for _, options := range []ResizeOptions{{Width: -1}, {Height: imaging.MaxDimension + 1}, {Fit: "stretch"}} {
+		if _, err := options.Normalize(); err != imaging.ErrInvalidTransform {
+			t.Errorf("%+v: %v, want ErrInvalidTransform", options, err)
+		}
+	}
+}
@@ -3,12 +3,14 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"os"
 
 	"github.com/condrowiyono/ruangtengah-api/app/model"
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -35,15 +37,7 @@ func Login(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusUnauthorized, "wrong password")
 		return
 	}
-	ttl := 720 * time.Hour
-
-	sign := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       user.ID,
-		"username": user.Username,
-		"email":    user.Email,
-		"exp":      time.Now().UTC().Add(ttl).Unix(),
-	})
-	token, err := sign.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	token, err := createToken(user, 0)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -66,7 +60,7 @@ func Register(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		Email:    user.Email,
 		Username: user.Username,
 		Name:     user.Name,
-		Role:     user.Role,
+		Role:     model.RoleUser, // role of the request is ignored, only an admin can promote
 		Password: string(hashedPassword),
 	}
 
@@ -77,13 +71,65 @@ func Register(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, nil, user)
 }
 
+// SetUserRoleRequest is the new role of a user
+type SetUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// SetUserRole promotes a user to admin or demotes to user
+func SetUserRole(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	request := SetUserRoleRequest{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if !model.IsRole(request.Role) {
+		respondError(w, http.StatusBadRequest, "role must be admin or user")
+		return
+	}
+
+	user := model.User{}
+	if id == 0 || db.Where("id = ?", id).First(&user).Error != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if err := db.Model(&user).Update("role", request.Role).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, user)
+}
+
 func GetMyDetail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	user := model.User{}
 	username := r.Header.Get("username")
-	if err = db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err = db.Where("username = ?", username).Preload("Profiles").First(&user).Error; err != nil {
 		respondError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, nil, user)
 }
+
+// createToken sign JWT of the user, profileID is included when a profile is selected
+func createToken(user model.User, profileID uint) (string, error) {
+	ttl := 720 * time.Hour
+
+	claims := jwt.MapClaims{
+		"id":       user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"exp":      time.Now().UTC().Add(ttl).Unix(),
+	}
+	if profileID != 0 {
+		claims["profile_id"] = profileID
+	}
+
+	sign := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return sign.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/condrowiyono/ruangtengah-api/app/cache"
+)
+
+// GetProviderCache shows entries, hits and misses of the provider cache
+func GetProviderCache(c *cache.Cache, w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, nil, c.Stats())
+}
+
+// PurgeProviderCache removes cached provider responses of key starting with prefix, ex: tmdb:movie:27205.
+// Without prefix every entry is removed
+func PurgeProviderCache(c *cache.Cache, w http.ResponseWriter, r *http.Request) {
+	purged := c.Purge(r.URL.Query().Get("prefix"))
+	respondJSON(w, http.StatusOK, nil, map[string]int{"purged": purged})
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+const defaultRatingCountry = "ID"
+
+// AgeLimit is parental control applied to a request
+type AgeLimit struct {
+	Country string
+	MaxAge  int
+}
+
+type ParentalControlRequest struct {
+	MaxRating     string `json:"max_rating"`
+	RatingCountry string `json:"rating_country"`
+}
+
+// UpdateParentalControl set maximum rating of logged in user, empty rating remove the restriction
+func UpdateParentalControl(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	user := model.User{}
+	username := r.Header.Get("username")
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	request := ParentalControlRequest{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if len(request.RatingCountry) == 0 {
+		request.RatingCountry = defaultRatingCountry
+	}
+	if !isValidMaxRating(db, request.RatingCountry, request.MaxRating) {
+		respondError(w, http.StatusBadRequest, "max_rating is not a rating of "+request.RatingCountry)
+		return
+	}
+
+	if err := db.Model(&user).Updates(map[string]interface{}{
+		"max_rating":     request.MaxRating,
+		"rating_country": request.RatingCountry,
+	}).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, user)
+}
+
+// isValidMaxRating tells whether rating is a rating of the country, empty rating is no restriction.
+// Unknown rating would fall back to UnratedAge and allow everything
+func isValidMaxRating(db *gorm.DB, country string, rating string) bool {
+	if len(rating) == 0 {
+		return true
+	}
+	if len(country) == 0 {
+		country = defaultRatingCountry
+	}
+	return model.IsKnownRating(db, country, rating)
+}
+
+// getAgeLimit returns parental control of logged in user, nil when unrestricted.
+// Kids or rating of the active profile take precedence over the user rating
+func getAgeLimit(db *gorm.DB, r *http.Request) *AgeLimit {
+	username := r.Header.Get("username")
+	if len(username) == 0 {
+		return nil
+	}
+
+	user := model.User{}
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil
+	}
+
+	country := user.RatingCountry
+	if len(country) == 0 {
+		country = defaultRatingCountry
+	}
+
+	if profile := getActiveProfile(db, r); profile != nil {
+		if profile.Kids {
+			return &AgeLimit{Country: country, MaxAge: model.KidsMaxAge}
+		}
+		if len(profile.MaxRating) != 0 {
+			return &AgeLimit{Country: country, MaxAge: model.CertificationAge(country, profile.MaxRating)}
+		}
+	}
+
+	if len(user.MaxRating) == 0 {
+		return nil
+	}
+	return &AgeLimit{Country: country, MaxAge: model.CertificationAge(country, user.MaxRating)}
+}
+
+// restrictByAge hides shows above the age limit.
+// Rating of the limit country is preferred, then the strictest of other countries
+func restrictByAge(query *gorm.DB, table string, limit *AgeLimit) *gorm.DB {
+	if limit == nil {
+		return query
+	}
+
+	return query.Where(`COALESCE(
+		(SELECT MAX(c.min_age) FROM certifications c WHERE c.deleted_at IS NULL AND c.show_type = ? AND c.show_id = `+table+`.id AND c.country = ?),
+		(SELECT MAX(c.min_age) FROM certifications c WHERE c.deleted_at IS NULL AND c.show_type = ? AND c.show_id = `+table+`.id),
+		?) <= ?`, table, limit.Country, table, model.UnratedAge, limit.MaxAge)
+}
+
+// isAllowedByAge checks a single show against the age limit
+func isAllowedByAge(db *gorm.DB, table string, id uint, limit *AgeLimit) bool {
+	if limit == nil {
+		return true
+	}
+
+	var count int
+	restrictByAge(db.Table(table), table, limit).Where(table+".id = ?", id).Count(&count)
+	return count > 0
+}
+
+// isPlayerAllowedByAge checks the show of a player against the age limit. Episodes aren't rated,
+// they follow the rating of their tv. Player of an unknown show type isn't restricted
+func isPlayerAllowedByAge(db *gorm.DB, showType string, showID uint, limit *AgeLimit) bool {
+	if limit == nil || showTable(showType) != showType {
+		return true
+	}
+	if showType != "tv_episodes" {
+		return isAllowedByAge(db, showType, showID, limit)
+	}
+
+	tvIDs := []uint{}
+	db.Table("tv_episodes").
+		Joins("JOIN tv_seasons ON tv_seasons.id = tv_episodes.tv_season_id AND tv_seasons.deleted_at IS NULL").
+		Where("tv_episodes.id = ?", showID).
+		Pluck("tv_seasons.tv_id", &tvIDs)
+	if len(tvIDs) == 0 {
+		// Episode without season is unrated
+		return isAllowedByAge(db, showType, showID, limit)
+	}
+	return isAllowedByAge(db, "tvs", tvIDs[0], limit)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/condrowiyono/ruangtengah-api/app/gallery"
 	"github.com/condrowiyono/ruangtengah-api/app/model"
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
@@ -17,6 +18,7 @@ func GetAllConcert(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	page := string(vars.Get("page"))
 	limit := string(vars.Get("limit"))
 	title := string(vars.Get("title"))
+	sort := string(vars.Get("sort"))
 
 	pageInt, err := strconv.Atoi(page)
 	if err != nil {
@@ -38,21 +40,34 @@ func GetAllConcert(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		query = query.Where("title LIKE ?", fmt.Sprintf("%%%s%%", title))
 	}
 
+	if sort == "trending" {
+		query = sortByTrending(query, "concerts", model.TrendingWeek)
+	}
+
+	query = restrictByAge(query, "concerts", getAgeLimit(db, r))
+
 	query = query.
-		Where("id IN (?)", queryWhereIn.QueryExpr()).
+		Where("concerts.id IN (?)", queryWhereIn.QueryExpr()).
 		Limit(limitInt).
 		Offset(offsetInt).
 		Preload("Banners").
 		Preload("Posters").
+		Preload("Images", orderedImages).
+		Preload("Images.Image").
 		Preload("Artist").
-		Preload("Player").
-		Preload("Videos")
+		Preload("Players", enabledPlayers).
+		Preload("Videos", workingLinks).
+		Preload("Certifications")
 
 	if err := query.Find(&concert).Error; err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	for i := range concert {
+		signPlayers(concert[i].Players, r)
+	}
+
 	var count int64
 	query = query.Offset(0).
 		Count(&count)
@@ -76,6 +91,10 @@ func CreateConcert(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if err := gallery.SyncLegacyImages(db, "concerts", int(concert.ID)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	respondJSON(w, http.StatusCreated, nil, concert)
 }
@@ -88,6 +107,11 @@ func GetConcert(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	if concert == nil {
 		return
 	}
+	if !isAllowedByAge(db, "concerts", concert.ID, getAgeLimit(db, r)) {
+		respondError(w, http.StatusForbidden, "restricted by parental control")
+		return
+	}
+	signPlayers(concert.Players, r)
 	respondJSON(w, http.StatusOK, nil, concert)
 }
 
@@ -111,6 +135,11 @@ func UpdateConcert(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	db.Model(&concert).Association("Certifications").Replace(concert.Certifications)
+	if err := gallery.SyncLegacyImages(db, "concerts", int(concert.ID)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	respondJSON(w, http.StatusOK, nil, concert)
 }
@@ -136,8 +165,11 @@ func getConcertOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Reque
 	if err := db.
 		Preload("Banners").
 		Preload("Posters").
-		Preload("Player").
-		Preload("Videos").
+		Preload("Images", orderedImages).
+		Preload("Images.Image").
+		Preload("Players", enabledPlayers).
+		Preload("Videos", workingLinks).
+		Preload("Certifications").
 		First(&concert, id).Error; err != nil {
 		respondError(w, http.StatusNotFound, err.Error())
 		return nil
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+func GetAllCuratedList(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	page := string(vars.Get("page"))
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		pageInt = 1
+	}
+
+	limit := string(vars.Get("limit"))
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		limitInt = 25
+	}
+
+	offsetInt := (pageInt - 1) * limitInt
+
+	query := db.Model(&model.CuratedList{})
+
+	// Draft list only visible to admin
+	if !model.IsAdmin(db, r.Header.Get("username")) {
+		query = query.Where("published = ?", true)
+	}
+
+	var count int64
+	query.Count(&count)
+
+	curatedList := []model.CuratedList{}
+	if err := query.
+		Limit(limitInt).
+		Offset(offsetInt).
+		Preload("Cover").
+		Find(&curatedList).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Write Response
+	meta := Meta{limitInt, offsetInt, pageInt, count}
+	respondJSON(w, http.StatusOK, meta, curatedList)
+}
+
+func CreateCuratedList(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	curatedList := model.CuratedList{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&curatedList); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	for i := range curatedList.Items {
+		curatedList.Items[i].Position = i
+	}
+
+	if err := db.Set("gorm:association_autoupdate", false).Create(&curatedList).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, curatedList)
+}
+
+func GetCuratedList(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	curatedList := getCuratedListOr404(db, id, w, r)
+	if curatedList == nil {
+		return
+	}
+
+	if !curatedList.Published && !model.IsAdmin(db, r.Header.Get("username")) {
+		respondError(w, http.StatusNotFound, "record not found")
+		return
+	}
+
+	ageLimit := getAgeLimit(db, r)
+	for i, item := range curatedList.Items {
+		curatedList.Items[i].Show = findShow(db, item.ShowType, item.ShowID, ageLimit)
+	}
+	respondJSON(w, http.StatusOK, nil, curatedList)
+}
+
+func UpdateCuratedList(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	curatedList := getCuratedListOr404(db, id, w, r)
+	if curatedList == nil {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&curatedList); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	for i := range curatedList.Items {
+		curatedList.Items[i].Position = i
+	}
+
+	if err := db.Set("gorm:association_autoupdate", false).Save(&curatedList).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	db.Model(&curatedList).Association("Items").Replace(curatedList.Items)
+
+	respondJSON(w, http.StatusOK, nil, curatedList)
+}
+
+func DeleteCuratedList(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	curatedList := getCuratedListOr404(db, id, w, r)
+	if curatedList == nil {
+		return
+	}
+	if err := db.Delete(&curatedList).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusNoContent, nil, nil)
+}
+
+// ReorderCuratedList set item position following the order of given item ids
+func ReorderCuratedList(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	curatedList := getCuratedListOr404(db, id, w, r)
+	if curatedList == nil {
+		return
+	}
+
+	request := ReorderRequest{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	tx := db.Begin()
+	for position, itemID := range request.IDs {
+		if err := tx.Model(&model.CuratedListItem{}).
+			Where("id = ? AND curated_list_id = ?", itemID, curatedList.ID).
+			Update("position", position).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	curatedList = getCuratedListOr404(db, id, w, r)
+	if curatedList == nil {
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, curatedList)
+}
+
+// getCuratedListOr404 gets a instance if exists, or respond the 404 error otherwise
+func getCuratedListOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Request) *model.CuratedList {
+	curatedList := model.CuratedList{}
+	if err := db.
+		Preload("Cover").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		First(&curatedList, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return &curatedList
+}
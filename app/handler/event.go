@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/analytics"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+const maxEventBatch = 100
+
+type EventRequest struct {
+	ViewerID string                `json:"viewer_id"`
+	Events   []model.PlaybackEvent `json:"events"`
+}
+
+type TitleStatResult struct {
+	ShowID         int     `json:"show_id"`
+	ShowType       string  `json:"show_type"`
+	Views          int     `json:"views"`
+	UniqueViewers  int     `json:"unique_viewers"`
+	Completions    int     `json:"completions"`
+	Errors         int     `json:"errors"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+var eventTypes = map[string]bool{
+	model.EventPlay:     true,
+	model.EventPause:    true,
+	model.EventSeek:     true,
+	model.EventComplete: true,
+	model.EventError:    true,
+}
+
+// CreateEvents accept batch of playback events and queue them to the recorder.
+// Event of anonymous viewer needs viewer_id of the request or its own session_id
+func CreateEvents(db *gorm.DB, recorder *analytics.Recorder, w http.ResponseWriter, r *http.Request) {
+	request := EventRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if len(request.Events) == 0 || len(request.Events) > maxEventBatch {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("events must contain 1 to %d items", maxEventBatch))
+		return
+	}
+
+	username := r.Header.Get("username")
+	viewer := ""
+	if len(username) != 0 {
+		viewer = "user:" + username
+	} else if len(request.ViewerID) != 0 {
+		viewer = "anonymous:" + request.ViewerID
+	}
+
+	// Every profile is counted as its own viewer
+	profileID := 0
+	if profile := getActiveProfile(db, r); profile != nil {
+		profileID = int(profile.ID)
+		viewer = fmt.Sprintf("profile:%d", profileID)
+	}
+
+	now := time.Now()
+	events := []model.PlaybackEvent{}
+	for i, v := range request.Events {
+		if !eventTypes[v.Type] {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("events[%d]: unknown type %q", i, v.Type))
+			return
+		}
+		if (v.PlayerID == 0) == (v.VideoID == 0) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("events[%d]: either player_id or video_id is required", i))
+			return
+		}
+
+		event := model.PlaybackEvent{
+			Type:       v.Type,
+			PlayerID:   v.PlayerID,
+			VideoID:    v.VideoID,
+			SessionID:  v.SessionID,
+			Viewer:     viewer,
+			Username:   username,
+			ProfileID:  profileID,
+			Position:   v.Position,
+			Duration:   v.Duration,
+			Message:    v.Message,
+			OccurredAt: v.OccurredAt,
+		}
+		// Trust client clock only when it is not in the future
+		if event.OccurredAt.IsZero() || event.OccurredAt.After(now) {
+			event.OccurredAt = now
+		}
+		// Without any id every anonymous play would be the same viewer and view,
+		// session alone is its own viewer
+		if len(event.SessionID) == 0 && len(viewer) == 0 {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("events[%d]: session_id is required without viewer_id", i))
+			return
+		}
+		if len(viewer) == 0 {
+			event.Viewer = "session:" + event.SessionID
+		}
+		if len(event.SessionID) == 0 {
+			event.SessionID = viewer
+		}
+		events = append(events, event)
+	}
+
+	recorder.Add(events...)
+	respondJSON(w, http.StatusAccepted, nil, map[string]interface{}{"accepted": len(events)})
+}
+
+// GetTitleStats return playback stats per title from daily rollups.
+// Unique viewers are summed from daily rollups, so a viewer is counted once per day
+func GetTitleStats(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	page := string(vars.Get("page"))
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		pageInt = 1
+	}
+
+	limit := string(vars.Get("limit"))
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		limitInt = 25
+	}
+
+	offsetInt := (pageInt - 1) * limitInt
+
+	to := string(vars.Get("to"))
+	if len(to) == 0 {
+		to = time.Now().Format(analytics.DateFormat)
+	}
+	from := string(vars.Get("from"))
+	if len(from) == 0 {
+		from = time.Now().AddDate(0, 0, -30).Format(analytics.DateFormat)
+	}
+	showType := string(vars.Get("show_type"))
+	showID := string(vars.Get("show_id"))
+
+	query := db.Model(&model.TitleStat{}).Where("date BETWEEN ? AND ?", from, to)
+	if len(showType) != 0 {
+		query = query.Where("show_type = ?", showType)
+	}
+
+	// Daily series of a single title
+	if len(showID) != 0 {
+		stats := []model.TitleStat{}
+		if err := query.Where("show_id = ?", showID).Order("date").Find(&stats).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, nil, stats)
+		return
+	}
+
+	query = query.
+		Select(`show_type, show_id,
+			SUM(views) AS views,
+			SUM(unique_viewers) AS unique_viewers,
+			SUM(completions) AS completions,
+			SUM(errors) AS errors`).
+		Group("show_type, show_id")
+
+	var count int64
+	db.Raw("SELECT COUNT(*) FROM (?) AS titles", query.QueryExpr()).Row().Scan(&count)
+
+	results := []TitleStatResult{}
+	if err := query.
+		Order("views DESC").
+		Limit(limitInt).
+		Offset(offsetInt).
+		Scan(&results).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i, result := range results {
+		if result.Views > 0 {
+			results[i].CompletionRate = float64(result.Completions) / float64(result.Views)
+		}
+	}
+
+	// Write Response
+	meta := Meta{limitInt, offsetInt, pageInt, count}
+	respondJSON(w, http.StatusOK, meta, results)
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+const defaultRailSize = 20
+
+// homeViewer is the requesting user whom the rails are personalized for
+type homeViewer struct {
+	Username string
+	Profile  *model.Profile
+	AgeLimit *AgeLimit
+}
+
+func GetHome(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	viewer := homeViewer{
+		Username: r.Header.Get("username"),
+		Profile:  getActiveProfile(db, r),
+		AgeLimit: getAgeLimit(db, r),
+	}
+
+	rails := []model.Rail{}
+	if err := db.
+		Where("enabled = ?", true).
+		Order("position").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Find(&rails).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	home := []model.Rail{}
+	for _, rail := range rails {
+		// Continue watching only make sense for logged in user
+		if rail.Type == model.RailContinueWatching && len(viewer.Username) == 0 {
+			continue
+		}
+
+		shows, err := getRailShows(db, rail, viewer)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		rail.Shows = shows
+		home = append(home, rail)
+	}
+
+	respondJSON(w, http.StatusOK, nil, home)
+}
+
+// getRailShows resolves the shows displayed on a rail based on its type
+func getRailShows(db *gorm.DB, rail model.Rail, viewer homeViewer) (interface{}, error) {
+	size := rail.Size
+	if size <= 0 {
+		size = defaultRailSize
+	}
+	table := showTable(rail.ShowType)
+	ageLimit := viewer.AgeLimit
+
+	switch rail.Type {
+	case model.RailNewlyAdded:
+		query := restrictByAge(db, table, ageLimit).
+			Order("created_at DESC").
+			Limit(size)
+		return findShows(query, rail.ShowType)
+
+	case model.RailGenre:
+		if table == "concerts" {
+			// Concert has no genre
+			return []interface{}{}, nil
+		}
+		joinTable := map[string]string{"movies": "movies_genres", "tvs": "tv_genres"}[table]
+		foreignKey := map[string]string{"movies": "movie_id", "tvs": "tv_id"}[table]
+
+		query := restrictByAge(db, table, ageLimit).
+			Joins("join "+joinTable+" on "+joinTable+"."+foreignKey+" = "+table+".id").
+			Joins("join genres on genres.id = "+joinTable+".genre_id AND genres.name = ?", rail.Genre).
+			Order(table + ".created_at DESC").
+			Limit(size)
+		return findShows(query, rail.ShowType)
+
+	case model.RailEditorial:
+		items := rail.Items
+		if rail.CuratedListID != 0 {
+			curatedList := model.CuratedList{}
+			if err := db.
+				Where("published = ?", true).
+				Preload("Items", func(db *gorm.DB) *gorm.DB {
+					return db.Order("position")
+				}).
+				First(&curatedList, rail.CuratedListID).Error; err != nil {
+				return []interface{}{}, nil
+			}
+
+			items = []model.RailItem{}
+			for _, item := range curatedList.Items {
+				items = append(items, model.RailItem{ShowID: item.ShowID, ShowType: item.ShowType})
+			}
+		}
+
+		shows := []interface{}{}
+		for _, item := range items {
+			if len(shows) >= size {
+				break
+			}
+			if show := findShow(db, item.ShowType, item.ShowID, ageLimit); show != nil {
+				shows = append(shows, show)
+			}
+		}
+		return shows, nil
+
+	case model.RailTrending:
+		query := sortByTrending(restrictByAge(db, table, ageLimit), table, model.TrendingWeek).
+			Where("trending_scores.score > 0").
+			Limit(size)
+		return findShows(query, rail.ShowType)
+
+	case model.RailContinueWatching:
+		return findContinueWatching(db, viewer, size)
+	}
+
+	return []interface{}{}, nil
+}
+
+// findContinueWatching finds shows the viewer played recently but not completed yet.
+// Only progress of the active profile is used when a profile is selected
+func findContinueWatching(db *gorm.DB, viewer homeViewer, size int) (interface{}, error) {
+	type playedShow struct {
+		ShowID   int
+		ShowType string
+	}
+
+	condition := "username = ?"
+	value := interface{}(viewer.Username)
+	if viewer.Profile != nil {
+		condition = "profile_id = ?"
+		value = viewer.Profile.ID
+	}
+
+	played := []playedShow{}
+	if err := db.Raw(`SELECT show_id, show_type
+		FROM playback_events
+		WHERE deleted_at IS NULL AND `+condition+` AND show_id <> 0
+		GROUP BY show_type, show_id
+		HAVING SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) = 0
+		ORDER BY MAX(occurred_at) DESC
+		LIMIT ?`, value, model.EventComplete, size).
+		Scan(&played).Error; err != nil {
+		return nil, err
+	}
+
+	shows := []interface{}{}
+	for _, v := range played {
+		if show := findShow(db, v.ShowType, v.ShowID, viewer.AgeLimit); show != nil {
+			shows = append(shows, show)
+		}
+	}
+	return shows, nil
+}
+
+// showTable normalize show type into its table name, default to movies
+func showTable(showType string) string {
+	switch showType {
+	case "tvs", "tv":
+		return "tvs"
+	case "concerts", "concert":
+		return "concerts"
+	case "tv_episodes", "episode":
+		return "tv_episodes"
+	}
+	return "movies"
+}
+
+// findShows finds list of show with preloaded images for the given type
+func findShows(query *gorm.DB, showType string) (interface{}, error) {
+	switch showTable(showType) {
+	case "tvs":
+		tvs := []model.Tv{}
+		err := query.Preload("Posters").Preload("Banners").Preload("Genres").Find(&tvs).Error
+		return tvs, err
+	case "concerts":
+		concerts := []model.Concert{}
+		err := query.Preload("Banners").Preload("Artist").Find(&concerts).Error
+		return concerts, err
+	}
+
+	movies := []model.Movie{}
+	err := query.Preload("Posters").Preload("Banners").Preload("Genres").Find(&movies).Error
+	return movies, err
+}
+
+// findShow finds a single show, return nil when it does not exist or restricted by age limit
+func findShow(db *gorm.DB, showType string, id int, ageLimit *AgeLimit) interface{} {
+	table := showTable(showType)
+	db = restrictByAge(db, table, ageLimit)
+
+	switch table {
+	case "tvs":
+		tv := model.Tv{}
+		if err := db.Preload("Posters").Preload("Banners").Preload("Genres").First(&tv, id).Error; err != nil {
+			return nil
+		}
+		return tv
+	case "concerts":
+		concert := model.Concert{}
+		if err := db.Preload("Banners").Preload("Artist").First(&concert, id).Error; err != nil {
+			return nil
+		}
+		return concert
+	case "tv_episodes":
+		episode := model.TvEpisode{}
+		if err := db.First(&episode, id).Error; err != nil {
+			return nil
+		}
+		return episode
+	}
+
+	movie := model.Movie{}
+	if err := db.Preload("Posters").Preload("Banners").Preload("Genres").First(&movie, id).Error; err != nil {
+		return nil
+	}
+	return movie
+}
@@ -2,13 +2,17 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/condrowiyono/ruangtengah-api/app/gallery"
+	"github.com/condrowiyono/ruangtengah-api/app/imaging"
 	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 )
@@ -115,47 +119,269 @@ func DeleteImage(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusNoContent, nil, nil)
 }
 
-func UploadImage(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+type ImportImageRequest struct {
+	URL     string `json:"url"`
+	Type    string `json:"type"`
+	Keyword string `json:"keyword"`
+	Source  string `json:"source"`
+}
+
+// UploadImage validates the uploaded image then stores the original and its renditions.
+// Metadata like EXIF is not kept
+func UploadImage(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
 	r.ParseMultipartForm(10 << 20)
 
-	file, handler, err := r.FormFile("file")
+	file, _, err := r.FormFile("file")
 	if err != nil {
-		fmt.Println("Error Retrieving the File")
-		fmt.Println(err)
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	defer file.Close()
 
-	fmt.Printf("Uploaded File: %+v\n", handler.Filename)
-
-	tempFile, err := ioutil.TempFile("uploads", "upload-*.png")
+	fileBytes, err := ioutil.ReadAll(file)
 	if err != nil {
-		fmt.Println(err)
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	defer tempFile.Close()
 
-	fileBytes, err := ioutil.ReadAll(file)
-	if err != nil {
-		fmt.Println(err)
+	// Same file is stored once
+	if duplicate := gallery.FindDuplicate(db, fileBytes); duplicate != nil {
+		respondJSON(w, http.StatusOK, nil, duplicate)
+		return
 	}
 
-	tempFile.Write(fileBytes)
+	keyword := r.FormValue("keyword")
+	if len(keyword) == 0 {
+		keyword = "keyword"
+	}
 
 	//Save to DB
 	image := model.Image{
-		Path:    fmt.Sprintf("%s/%s", os.Getenv("BASE_URL"), tempFile.Name()),
+		Type:    r.FormValue("type"),
 		Source:  "upload",
-		Keyword: "keyword",
+		Keyword: keyword,
+	}
+	if err := gallery.Ingest(db, store, fileBytes, &image); err != nil {
+		respondIngestError(w, err)
+		return
 	}
 
-	if err := db.Save(&image).Error; err != nil {
+	respondJSON(w, http.StatusOK, nil, image)
+}
+
+// ImportImageURL downloads a remote image into our storage, ex: a TMDB poster or a search result.
+// Source is the origin name, the host of the URL by default
+func ImportImageURL(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	request := ImportImageRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	image, created, err := importImageURL(db, store, request.URL, model.Image{
+		Type:    request.Type,
+		Keyword: request.Keyword,
+		Source:  request.Source,
+	})
+	if err != nil {
+		respondIngestError(w, err)
+		return
+	}
+	if !created {
+		respondJSON(w, http.StatusOK, nil, image)
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, image)
+}
+
+// importImageURL downloads the image at rawURL unless the same URL or file is already stored,
+// it returns the stored one then. Source of the image is the host of the URL when empty
+func importImageURL(db *gorm.DB, store storage.Storage, rawURL string, image model.Image) (*model.Image, bool, error) {
+	existing := model.Image{}
+	if err := db.Where("origin_url = ? AND storage_keys IS NOT NULL", rawURL).First(&existing).Error; err == nil {
+		return &existing, false, nil
+	}
+
+	data, err := gallery.Fetch(rawURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if duplicate := gallery.FindDuplicate(db, data); duplicate != nil {
+		return duplicate, false, nil
+	}
+
+	if len(image.Source) == 0 {
+		if parsed, err := url.Parse(rawURL); err == nil {
+			image.Source = parsed.Hostname()
+		}
+	}
+	image.OriginURL = rawURL
+	if err := gallery.Ingest(db, store, data, &image); err != nil {
+		return nil, false, err
+	}
+	return &image, true, nil
+}
+
+// GetResizedImage serves the image resized on the fly, ex: /img/1?w=300&h=450&fit=cover&format=webp.
+// The longer side is rounded up to one of gallery.ResizeSizes keeping the aspect ratio, ex: w=300&h=450 is served 333x500.
+// WebP is answered as JPEG or PNG since it can't be encoded, the Content-Type tells the real format
+func GetResizedImage(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	image := getImageOr404(db, id, w, r)
+	if image == nil {
+		return
+	}
+
+	options := gallery.ResizeOptions{Fit: query.Get("fit"), Format: query.Get("format")}
+	var err error
+	if width := query.Get("w"); len(width) != 0 {
+		if options.Width, err = strconv.Atoi(width); err != nil {
+			respondError(w, http.StatusBadRequest, "w must be a number")
+			return
+		}
+	}
+	if height := query.Get("h"); len(height) != 0 {
+		if options.Height, err = strconv.Atoi(height); err != nil {
+			respondError(w, http.StatusBadRequest, "h must be a number")
+			return
+		}
+	}
+
+	switch options.Format {
+	case "", imaging.FormatPNG, imaging.FormatWebP:
+	case imaging.FormatJPEG, "jpg":
+		options.Format = imaging.FormatJPEG
+	default:
+		respondError(w, http.StatusBadRequest, "format must be jpeg, png or webp")
+		return
+	}
+
+	filename, etag, err := gallery.Resized(store, image, options)
+	if err == imaging.ErrInvalidTransform {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondIngestError(w, err)
+		return
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Same URL always gives the same content
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
+
+type MergeImageRequest struct {
+	KeepID uint   `json:"keep_id"`
+	IDs    []uint `json:"ids"`
+}
+
+// GetDuplicateImages list clusters of near duplicate images, distance is the allowed different bits of their hash
+func GetDuplicateImages(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	distance, err := strconv.Atoi(string(vars.Get("distance")))
+	if err != nil {
+		distance = 6
+	}
+	if distance < 0 || distance > 16 {
+		respondError(w, http.StatusBadRequest, "distance must be between 0 and 16")
+		return
+	}
+
+	clusters, err := gallery.Clusters(db, distance)
+	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	respondJSON(w, http.StatusOK, nil, clusters)
+}
+
+// MergeImages re-points every owner, curated list and profile using the images to keep_id
+func MergeImages(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	request := MergeImageRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
 
+	image := getImageOr404(db, int64(request.KeepID), w, r)
+	if image == nil {
+		return
+	}
+
+	if err := gallery.Merge(db, image.ID, request.IDs); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 	respondJSON(w, http.StatusOK, nil, image)
 }
 
+// GetImageGarbage reports unused images and orphan files without deleting them, grace overrides IMAGE_GC_GRACE
+func GetImageGarbage(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	collectImageGarbage(db, store, true, w, r)
+}
+
+// CollectImageGarbage deletes unused images and orphan files, grace overrides IMAGE_GC_GRACE
+func CollectImageGarbage(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	collectImageGarbage(db, store, false, w, r)
+}
+
+func collectImageGarbage(db *gorm.DB, store storage.Storage, dryRun bool, w http.ResponseWriter, r *http.Request) {
+	grace := gallery.GraceFromEnv()
+	if value := r.URL.Query().Get("grace"); len(value) != 0 {
+		var err error
+		if grace, err = time.ParseDuration(value); err != nil || grace < 0 {
+			respondError(w, http.StatusBadRequest, "grace must be a duration, ex: 72h")
+			return
+		}
+	}
+
+	report, err := gallery.Collect(db, store, grace, dryRun)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, report)
+}
+
+// respondIngestError maps error of the image pipeline to the response status
+func respondIngestError(w http.ResponseWriter, err error) {
+	if _, ok := err.(*gallery.DownloadError); ok {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if err == imaging.ErrUnsupported || err == imaging.ErrTooLarge {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	respondError(w, http.StatusInternalServerError, err.Error())
+}
+
 // getImageOr404 gets a instance if exists, or respond the 404 error otherwise
 func getImageOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Request) *model.Image {
 	image := model.Image{}
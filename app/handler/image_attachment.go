@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/gallery"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// imageOwnerTables maps owner of the URL, ex: /movie/1/images, to its table
+var imageOwnerTables = map[string]string{
+	"movie":   "movies",
+	"tv":      "tvs",
+	"season":  "tv_seasons",
+	"episode": "tv_episodes",
+	"concert": "concerts",
+	"person":  "people",
+}
+
+type AttachImageRequest struct {
+	ImageID  uint   `json:"image_id"`
+	Role     string `json:"role"`
+	Language string `json:"language"`
+	Primary  bool   `json:"primary"`
+}
+
+// GetImageAttachments list images of the owner ordered by role and position, role filters them
+func GetImageAttachments(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	ownerType, ownerID := getImageOwnerOr404(db, w, r)
+	if ownerID == 0 {
+		return
+	}
+
+	query := db.Where("owner_id = ? AND owner_type = ?", ownerID, ownerType)
+	if role := r.URL.Query().Get("role"); len(role) != 0 {
+		query = query.Where("role = ?", role)
+	}
+
+	attachments := []model.ImageAttachment{}
+	if err := query.Scopes(orderedImages).Preload("Image").Find(&attachments).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, attachments)
+}
+
+// AttachImage puts the image last of its role, the first image of a role is primary
+func AttachImage(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	ownerType, ownerID := getImageOwnerOr404(db, w, r)
+	if ownerID == 0 {
+		return
+	}
+
+	request := AttachImageRequest{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if !model.IsImageRole(request.Role) {
+		respondError(w, http.StatusBadRequest, "role must be poster, banner, logo, still or profile")
+		return
+	}
+	if request.ImageID == 0 {
+		respondError(w, http.StatusBadRequest, "image_id is required")
+		return
+	}
+	image := getImageOr404(db, int64(request.ImageID), w, r)
+	if image == nil {
+		return
+	}
+
+	var count int
+	db.Model(&model.ImageAttachment{}).
+		Where("owner_id = ? AND owner_type = ? AND role = ?", ownerID, ownerType, request.Role).
+		Count(&count)
+
+	attachment := model.ImageAttachment{
+		ImageID:   image.ID,
+		OwnerID:   ownerID,
+		OwnerType: ownerType,
+		Role:      request.Role,
+		Language:  request.Language,
+		Position:  count,
+		IsPrimary: count == 0,
+	}
+
+	tx := db.Begin()
+	if err := tx.Create(&attachment).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := gallery.WriteLegacyImage(tx, attachment, true); err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if request.Primary && !attachment.IsPrimary {
+		if err := setPrimaryImage(tx, &attachment); err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	attachment.Image = *image
+	respondJSON(w, http.StatusCreated, nil, attachment)
+}
+
+// DetachImage removes the image from the owner, the next image of the role becomes primary.
+// The image itself is kept
+func DetachImage(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	attachment := getImageAttachmentOr404(db, w, r)
+	if attachment == nil {
+		return
+	}
+
+	tx := db.Begin()
+	if err := tx.Delete(attachment).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := gallery.WriteLegacyImage(tx, *attachment, false); err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if attachment.IsPrimary {
+		next := model.ImageAttachment{}
+		err := tx.Where("owner_id = ? AND owner_type = ? AND role = ?", attachment.OwnerID, attachment.OwnerType, attachment.Role).
+			Order("position").
+			First(&next).Error
+		if err == nil {
+			err = setPrimaryImage(tx, &next)
+		}
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusNoContent, nil, nil)
+}
+
+// ReorderImages set position of the owner images following the order of given ids
+func ReorderImages(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	ownerType, ownerID := getImageOwnerOr404(db, w, r)
+	if ownerID == 0 {
+		return
+	}
+
+	request := ReorderRequest{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	tx := db.Begin()
+	for position, id := range request.IDs {
+		if err := tx.Model(&model.ImageAttachment{}).
+			Where("id = ? AND owner_id = ? AND owner_type = ?", id, ownerID, ownerType).
+			Update("position", position).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	attachments := []model.ImageAttachment{}
+	db.Where("owner_id = ? AND owner_type = ?", ownerID, ownerType).
+		Scopes(orderedImages).
+		Preload("Image").
+		Find(&attachments)
+	respondJSON(w, http.StatusOK, nil, attachments)
+}
+
+// SetPrimaryImage makes the image primary of its role, replacing the previous one
+func SetPrimaryImage(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	attachment := getImageAttachmentOr404(db, w, r)
+	if attachment == nil {
+		return
+	}
+
+	tx := db.Begin()
+	if err := setPrimaryImage(tx, attachment); err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	db.First(&attachment.Image, attachment.ImageID)
+	respondJSON(w, http.StatusOK, nil, attachment)
+}
+
+func setPrimaryImage(db *gorm.DB, attachment *model.ImageAttachment) error {
+	if err := db.Model(&model.ImageAttachment{}).
+		Where("owner_id = ? AND owner_type = ? AND role = ?", attachment.OwnerID, attachment.OwnerType, attachment.Role).
+		Update("is_primary", false).Error; err != nil {
+		return err
+	}
+	attachment.IsPrimary = true
+	return db.Model(attachment).Update("is_primary", true).Error
+}
+
+// orderedImages puts primary image first then follow the position
+func orderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("role").Order("is_primary DESC").Order("position")
+}
+
+// getImageOwnerOr404 returns table and id of the owner if exists, or respond the 404 error and zero id otherwise
+func getImageOwnerOr404(db *gorm.DB, w http.ResponseWriter, r *http.Request) (string, int) {
+	vars := mux.Vars(r)
+
+	table, ok := imageOwnerTables[vars["owner"]]
+	id, _ := strconv.Atoi(vars["id"])
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown image owner")
+		return "", 0
+	}
+
+	var count int
+	db.Table(table).Where("id = ? AND deleted_at IS NULL", id).Count(&count)
+	if count == 0 {
+		respondError(w, http.StatusNotFound, "record not found")
+		return "", 0
+	}
+	return table, id
+}
+
+// getImageAttachmentOr404 gets a instance of the owner if exists, or respond the 404 error otherwise
+func getImageAttachmentOr404(db *gorm.DB, w http.ResponseWriter, r *http.Request) *model.ImageAttachment {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["attachment"], 10, 64)
+	attachment := model.ImageAttachment{}
+	if err := db.
+		Where("id = ? AND owner_id = ? AND owner_type = ?", id, vars["id"], imageOwnerTables[vars["owner"]]).
+		First(&attachment).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return &attachment
+}
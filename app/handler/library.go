@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/library"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+type MatchMediaRequest struct {
+	ShowID   uint   `json:"show_id"`
+	ShowType string `json:"show_type"`
+}
+
+// ScanLibrary scans the media library right away and returns the report
+func ScanLibrary(db *gorm.DB, providers provider.Providers, w http.ResponseWriter, r *http.Request) {
+	report, err := library.NewScannerFromEnv(db, providers).Scan()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, report)
+}
+
+// GetUnmatchedMedia list library files waiting for manual review
+func GetUnmatchedMedia(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	page := string(vars.Get("page"))
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		pageInt = 1
+	}
+
+	limit := string(vars.Get("limit"))
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		limitInt = 25
+	}
+
+	offsetInt := (pageInt - 1) * limitInt
+
+	query := db.Model(&model.UnmatchedMedia{})
+	kind := string(vars.Get("kind"))
+	if len(kind) != 0 {
+		query = query.Where("kind = ?", kind)
+	}
+
+	unmatched := []model.UnmatchedMedia{}
+	if err := query.Order("path").Limit(limitInt).Offset(offsetInt).Find(&unmatched).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var count int64
+	query.Count(&count)
+
+	meta := Meta{limitInt, offsetInt, pageInt, count}
+	respondJSON(w, http.StatusOK, meta, unmatched)
+}
+
+// MatchUnmatchedMedia creates the local player of an unmatched file to the given show
+func MatchUnmatchedMedia(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	unmatched := model.UnmatchedMedia{}
+	if err := db.First(&unmatched, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	request := MatchMediaRequest{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if request.ShowType != "movies" && request.ShowType != "concerts" && request.ShowType != "tv_episodes" {
+		respondError(w, http.StatusBadRequest, "show_type must be movies, concerts or tv_episodes")
+		return
+	}
+
+	var count int
+	db.Table(request.ShowType).Where("id = ? AND deleted_at IS NULL", request.ShowID).Count(&count)
+	if count == 0 {
+		respondError(w, http.StatusNotFound, "show not found")
+		return
+	}
+
+	player, err := library.AddPlayer(db, unmatched.Path, unmatched.Quality, request.ShowID, request.ShowType)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, player)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+type BrokenLinkResult struct {
+	Players []model.Player `json:"players"`
+	Videos  []model.Video  `json:"videos"`
+}
+
+// GetBrokenLinks list players and videos which failed the last health check
+func GetBrokenLinks(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	showType := string(vars.Get("show_type"))
+
+	query := db.Where("broken = ?", true)
+	if len(showType) != 0 {
+		query = query.Where("show_type = ?", showType)
+	}
+
+	result := BrokenLinkResult{Players: []model.Player{}, Videos: []model.Video{}}
+	if err := query.Order("link_checked_at DESC").Find(&result.Players).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := query.Order("link_checked_at DESC").Find(&result.Videos).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, result)
+}
+
+// workingLinks hides source which failed the last health check
+func workingLinks(db *gorm.DB) *gorm.DB {
+	return db.Where("broken = ?", false)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/condrowiyono/ruangtengah-api/app/gallery"
 	"github.com/condrowiyono/ruangtengah-api/app/model"
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
@@ -18,6 +19,7 @@ func GetAllMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	limit := string(vars.Get("limit"))
 	genre := string(vars.Get("genre"))
 	title := string(vars.Get("title"))
+	sort := string(vars.Get("sort"))
 
 	pageInt, err := strconv.Atoi(page)
 	if err != nil {
@@ -45,25 +47,38 @@ func GetAllMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		query = query.Where("title LIKE ?", fmt.Sprintf("%%%s%%", title))
 	}
 
+	if sort == "trending" {
+		query = sortByTrending(query, "movies", model.TrendingWeek)
+	}
+
+	query = restrictByAge(query, "movies", getAgeLimit(db, r))
+
 	query = query.
-		Where("id IN (?)", queryWhereIn.QueryExpr()).
+		Where("movies.id IN (?)", queryWhereIn.QueryExpr()).
 		Limit(limitInt).
 		Offset(offsetInt).
 		Preload("Genres").
 		Preload("Banners").
 		Preload("Posters").
+		Preload("Images", orderedImages).
+		Preload("Images.Image").
 		Preload("Countries").
 		Preload("Productions").
 		Preload("Actors").
 		Preload("Crews").
-		Preload("Player").
-		Preload("Videos")
+		Preload("Players", enabledPlayers).
+		Preload("Videos", workingLinks).
+		Preload("Certifications")
 
 	if err := query.Find(&movie).Error; err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	for i := range movie {
+		signPlayers(movie[i].Players, r)
+	}
+
 	var count int64
 	query = query.Offset(0).
 		Count(&count)
@@ -102,14 +117,12 @@ func CreateMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, v := range movie.Actors {
-		actor := model.Person{}
-		db.Where(model.Person{Name: v.Name}).FirstOrCreate(&actor)
+		actor := findOrCreatePerson(db, v)
 		actors = append(actors, actor)
 	}
 
 	for _, v := range movie.Crews {
-		crew := model.Person{}
-		db.Where(model.Person{Name: v.Name}).FirstOrCreate(&crew)
+		crew := findOrCreatePerson(db, v)
 		crews = append(crews, crew)
 	}
 
@@ -130,6 +143,10 @@ func CreateMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	db.Model(&movie).Association("Crews").Replace(crews)
 	db.Model(&movie).Association("Countries").Replace(countries)
 	db.Model(&movie).Association("Productions").Replace(productions)
+	if err := gallery.SyncLegacyImages(db, "movies", int(movie.ID)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	respondJSON(w, http.StatusCreated, nil, movie)
 }
@@ -142,6 +159,11 @@ func GetMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	if movie == nil {
 		return
 	}
+	if !isAllowedByAge(db, "movies", movie.ID, getAgeLimit(db, r)) {
+		respondError(w, http.StatusForbidden, "restricted by parental control")
+		return
+	}
+	signPlayers(movie.Players, r)
 	respondJSON(w, http.StatusOK, nil, movie)
 }
 
@@ -180,14 +202,12 @@ func UpdateMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, v := range movie.Actors {
-		actor := model.Person{}
-		db.Where(model.Person{Name: v.Name}).FirstOrCreate(&actor)
+		actor := findOrCreatePerson(db, v)
 		actors = append(actors, actor)
 	}
 
 	for _, v := range movie.Crews {
-		crew := model.Person{}
-		db.Where(model.Person{Name: v.Name}).FirstOrCreate(&crew)
+		crew := findOrCreatePerson(db, v)
 		crews = append(crews, crew)
 	}
 
@@ -209,9 +229,13 @@ func UpdateMovie(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	db.Model(&movie).Association("Country").Replace(countries)
 	db.Model(&movie).Association("Productions").Replace(productions)
 	db.Model(&movie).Association("Videos").Replace(movie.Videos)
-	db.Model(&movie).Association("Player").Replace(movie.Player)
 	db.Model(&movie).Association("Banners").Replace(movie.Banners)
 	db.Model(&movie).Association("Posters").Replace(movie.Posters)
+	db.Model(&movie).Association("Certifications").Replace(movie.Certifications)
+	if err := gallery.SyncLegacyImages(db, "movies", int(movie.ID)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	respondJSON(w, http.StatusOK, nil, movie)
 }
@@ -238,12 +262,15 @@ func getMovieOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Request
 		Preload("Banners").
 		Preload("Genres").
 		Preload("Posters").
+		Preload("Images", orderedImages).
+		Preload("Images.Image").
 		Preload("Countries").
 		Preload("Productions").
 		Preload("Actors").
 		Preload("Crews").
-		Preload("Player").
-		Preload("Videos").
+		Preload("Players", enabledPlayers).
+		Preload("Videos", workingLinks).
+		Preload("Certifications").
 		First(&movie, id).Error; err != nil {
 		respondError(w, http.StatusNotFound, err.Error())
 		return nil
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 )
@@ -115,10 +118,140 @@ func DeletePerson(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusNoContent, nil, nil)
 }
 
+// ImportPerson creates or updates the person of a TMDB person id, ex: /person/import?tmdb=6193.
+// Person of the same TMDB id, or else of the same name without one, is updated instead of created.
+// Profile image is stored and becomes the primary profile and the picture
+func ImportPerson(db *gorm.DB, store storage.Storage, providers provider.Providers, w http.ResponseWriter, r *http.Request) {
+	tmdbID, err := strconv.Atoi(r.URL.Query().Get("tmdb"))
+	if err != nil || tmdbID <= 0 {
+		respondError(w, http.StatusBadRequest, "tmdb must be a TMDB person id")
+		return
+	}
+	source, ok := providers["tmdb"]
+	if !ok {
+		respondError(w, http.StatusNotImplemented, "tmdb provider is not configured")
+		return
+	}
+
+	details, err := source.Person(strconv.Itoa(tmdbID))
+	if err != nil {
+		respondError(w, provider.HTTPStatus(err), err.Error())
+		return
+	}
+
+	person := findPerson(db, tmdbID, details.Name)
+	created := person.ID == 0
+	person.TmdbID = tmdbID
+	if len(person.Name) == 0 {
+		person.Name = details.Name
+	}
+	// Empty value of TMDB doesn't clear what was typed by hand
+	for field, value := range map[*string]string{
+		&person.Bio:                details.Biography,
+		&person.Birthday:           details.Birthday,
+		&person.PlaceOfBirth:       details.PlaceOfBirth,
+		&person.KnownForDepartment: details.KnownForDepartment,
+	} {
+		if len(value) != 0 {
+			*field = value
+		}
+	}
+	if err := db.Save(&person).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Person is kept without the picture when the image can't be stored
+	if len(details.ProfileURL) != 0 {
+		if err := attachProfileImage(db, store, &person, details.ProfileURL); err != nil {
+			log.Println("person: import profile of tmdb", tmdbID, err)
+		}
+	}
+
+	db.Preload("Images", orderedImages).Preload("Images.Image").First(&person, person.ID)
+	if created {
+		respondJSON(w, http.StatusCreated, nil, person)
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, person)
+}
+
+// attachProfileImage stores the image and makes it the primary profile and the picture of the person
+func attachProfileImage(db *gorm.DB, store storage.Storage, person *model.Person, profileURL string) error {
+	image, _, err := importImageURL(db, store, profileURL, model.Image{Type: "profile_picture", Keyword: person.Name, Source: "tmdb"})
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	attachment := model.ImageAttachment{}
+	if tx.Where("owner_id = ? AND owner_type = ? AND role = ? AND image_id = ?", person.ID, "people", model.ImageProfile, image.ID).
+		First(&attachment).RecordNotFound() {
+		var count int
+		tx.Model(&model.ImageAttachment{}).
+			Where("owner_id = ? AND owner_type = ? AND role = ?", person.ID, "people", model.ImageProfile).
+			Count(&count)
+		attachment = model.ImageAttachment{
+			ImageID:   image.ID,
+			OwnerID:   int(person.ID),
+			OwnerType: "people",
+			Role:      model.ImageProfile,
+			Position:  count,
+		}
+		if err := tx.Create(&attachment).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := setPrimaryImage(tx, &attachment); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	person.Picture = image.Path
+	if err := tx.Model(person).Update("picture", image.Path).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// findOrCreatePerson returns the person of the same TMDB id, or of the same name as before TMDB ids.
+// Person found by name gets the TMDB id so the next import finds it by id
+func findOrCreatePerson(db *gorm.DB, v model.Person) model.Person {
+	person := findPerson(db, v.TmdbID, v.Name)
+	if person.ID == 0 {
+		person = model.Person{Name: v.Name, TmdbID: v.TmdbID}
+		db.Create(&person)
+		return person
+	}
+	if person.TmdbID == 0 && v.TmdbID != 0 {
+		db.Model(&person).Update("tmdb_id", v.TmdbID)
+	}
+	return person
+}
+
+// findPerson returns the person of the TMDB id, or else of the name. With a TMDB id only a person without one
+// matches the name since another id is another person of the same name. Person with zero id is returned when none
+func findPerson(db *gorm.DB, tmdbID int, name string) model.Person {
+	person := model.Person{}
+	if tmdbID != 0 && db.Where("tmdb_id = ?", tmdbID).First(&person).Error == nil {
+		return person
+	}
+
+	person = model.Person{}
+	query := db.Where("name = ?", name)
+	if tmdbID != 0 {
+		query = query.Where("tmdb_id = 0 OR tmdb_id IS NULL")
+	}
+	query.First(&person)
+	return person
+}
+
 // getPersonOr404 gets a instance if exists, or respond the 404 error otherwise
 func getPersonOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Request) *model.Person {
 	person := model.Person{}
-	if err := db.Preload("Pictures").First(&person, id).Error; err != nil {
+	if err := db.Preload("Pictures").Preload("Images", orderedImages).Preload("Images.Image").First(&person, id).Error; err != nil {
 		respondError(w, http.StatusNotFound, err.Error())
 		return nil
 	}
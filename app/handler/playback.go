@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/media"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/playback"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+const defaultPlaybackTTL = 15 * time.Minute
+
+// Headers passed between client and source when playback is proxied
+var (
+	proxyRequestHeaders  = []string{"Range", "If-Range", "If-Modified-Since", "If-None-Match"}
+	proxyResponseHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "Last-Modified", "ETag"}
+)
+
+// Play validates a signed playback URL then redirects to, or proxies, the real source.
+// Local player is served from the media root
+func Play(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	player := authorizePlayback(db, w, r)
+	if player == nil {
+		return
+	}
+
+	if player.Type == model.PlayerLocal {
+		// Playlist is served under the player path so relative segment URI resolves to PlayFile
+		if media.IsPlaylist(player.PlayerURL) {
+			http.Redirect(w, r, fmt.Sprintf("/play/%d/%s?%s", player.ID, path.Base(player.PlayerURL), r.URL.RawQuery), http.StatusFound)
+			return
+		}
+		serveMediaFile(w, r, player.PlayerURL)
+		return
+	}
+
+	if os.Getenv("PLAYBACK_PROXY") == "true" {
+		proxyPlayback(w, r, player.PlayerURL)
+		return
+	}
+	http.Redirect(w, r, player.PlayerURL, http.StatusFound)
+}
+
+// PlayFile serves segment, key and variant playlist referenced by the HLS playlist of a local player.
+// Other file in the same directory, ex: another title, is never served
+func PlayFile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	player := authorizePlayback(db, w, r)
+	if player == nil {
+		return
+	}
+	if player.Type != model.PlayerLocal || !media.IsPlaylist(player.PlayerURL) {
+		respondError(w, http.StatusNotFound, "player is not a local HLS playlist")
+		return
+	}
+
+	file := path.Clean(vars["file"])
+	if !media.IsSegment(file) {
+		respondError(w, http.StatusNotFound, "media not found")
+		return
+	}
+	// The playlist itself is what Play redirects to
+	if file != path.Base(player.PlayerURL) {
+		files, err := media.PlaylistFiles(media.Root(), player.PlayerURL)
+		if err != nil || !files[file] {
+			respondError(w, http.StatusNotFound, "media not found")
+			return
+		}
+	}
+
+	serveMediaFile(w, r, path.Join(path.Dir(player.PlayerURL), file))
+}
+
+// authorizePlayback checks signature, expiry, bound user and parental control of the player.
+// URL bound to a user is only playable with that user token
+func authorizePlayback(db *gorm.DB, w http.ResponseWriter, r *http.Request) *model.Player {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseUint(vars["id"], 10, 64)
+	username, err := playbackSigner().Verify(uint(id), r.URL.Query())
+	if err == playback.ErrExpired {
+		respondError(w, http.StatusGone, err.Error())
+		return nil
+	}
+	if err != nil {
+		respondError(w, http.StatusForbidden, err.Error())
+		return nil
+	}
+	if len(username) != 0 && username != r.Header.Get("username") {
+		respondError(w, http.StatusForbidden, "playback url belongs to another user")
+		return nil
+	}
+
+	player := model.Player{}
+	if err := db.Scopes(enabledPlayers).First(&player, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	if !isPlayerAllowedByAge(db, player.ShowType, uint(player.ShowID), getAgeLimit(db, r)) {
+		respondError(w, http.StatusForbidden, "restricted by parental control")
+		return nil
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return &player
+}
+
+// serveMediaFile serves a file under the media root with range support.
+// Playlist is rewritten so every segment keeps the signature
+func serveMediaFile(w http.ResponseWriter, r *http.Request, name string) {
+	filename, err := media.Resolve(media.Root(), name)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "media not found")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		respondError(w, http.StatusNotFound, "media not found")
+		return
+	}
+
+	if contentType := media.ContentType(filename); len(contentType) != 0 {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if media.IsPlaylist(filename) {
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		playlist := media.RewritePlaylist(data, r.URL.RawQuery)
+		http.ServeContent(w, r, filename, info.ModTime(), bytes.NewReader(playlist))
+		return
+	}
+	http.ServeContent(w, r, filename, info.ModTime(), file)
+}
+
+// proxyPlayback streams the source to the client, range request is passed through for seeking
+func proxyPlayback(w http.ResponseWriter, r *http.Request, source string) {
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	for _, header := range proxyRequestHeaders {
+		if value := r.Header.Get(header); len(value) != 0 {
+			req.Header.Set(header, value)
+		}
+	}
+
+	res, err := http.DefaultClient.Do(req.WithContext(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer res.Body.Close()
+
+	for _, header := range proxyResponseHeaders {
+		if value := res.Header.Get(header); len(value) != 0 {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	io.Copy(w, res.Body)
+}
+
+// signedPlayURL returns short lived URL of the player, bound to the requesting user when PLAYBACK_BIND_USER is set
+func signedPlayURL(playerID uint, r *http.Request) string {
+	username := ""
+	if os.Getenv("PLAYBACK_BIND_USER") == "true" {
+		username = r.Header.Get("username")
+	}
+
+	query := playbackSigner().Sign(playerID, username)
+	return fmt.Sprintf("%s/play/%d?%s", os.Getenv("BASE_URL"), playerID, query.Encode())
+}
+
+// signPlayers replace raw source of the players with signed URL
+func signPlayers(players []model.Player, r *http.Request) {
+	for i := range players {
+		players[i].PlayerURL = signedPlayURL(players[i].ID, r)
+	}
+}
+
+// playbackSigner use PLAYBACK_SECRET, or the JWT secret when it's not set
+func playbackSigner() *playback.Signer {
+	secret := os.Getenv("PLAYBACK_SECRET")
+	if len(secret) == 0 {
+		secret = os.Getenv("JWT_SECRET")
+	}
+
+	ttl, err := time.ParseDuration(os.Getenv("PLAYBACK_URL_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = defaultPlaybackTTL
+	}
+	return playback.NewSigner(secret, ttl)
+}
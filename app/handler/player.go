@@ -1,18 +1,23 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 )
 
 type PlayerResult struct {
-	Type      string `json:"type"`
-	PlayerURL string `json:"player_url"`
-	Title     string `json:"title"`
-	ID        string `json:"ID"`
+	Type      string           `json:"type"`
+	PlayerURL string           `json:"player_url"`
+	Title     string           `json:"title"`
+	ID        string           `json:"ID"`
+	Subtitles []model.Subtitle `json:"subtitles" gorm:"-"`
+	Sources   []model.Player   `json:"sources" gorm:"-"`
 }
 
 func GetPlayer(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
@@ -27,9 +32,147 @@ func GetPlayer(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	if player == nil {
 		return
 	}
+
+	showID, _ := strconv.ParseUint(player.ID, 10, 64)
+	if !isPlayerAllowedByAge(db, playerType, uint(showID), getAgeLimit(db, r)) {
+		respondError(w, http.StatusForbidden, "restricted by parental control")
+		return
+	}
+
+	player.Subtitles = []model.Subtitle{}
+	db.Where("player_id = ?", idInt).Order("is_default DESC, language").Find(&player.Subtitles)
+
+	// Every enabled player of the show, client fall back following this order
+	player.Sources = []model.Player{}
+	db.Scopes(enabledPlayers).
+		Where("show_id = ? AND show_type = ?", showID, playerType).
+		Preload("Subtitles").
+		Find(&player.Sources)
+
+	// Raw source is never exposed to public
+	player.PlayerURL = signedPlayURL(uint(idInt), r)
+	signPlayers(player.Sources, r)
+
 	respondJSON(w, http.StatusOK, nil, player)
 }
 
+// GetShowPlayers list every player of a show including the disabled one
+func GetShowPlayers(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	showID := string(vars.Get("show_id"))
+	showType := string(vars.Get("show_type"))
+
+	players := []model.Player{}
+	if err := db.
+		Where("show_id = ? AND show_type = ?", showID, showType).
+		Order("priority").
+		Preload("Subtitles").
+		Find(&players).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, players)
+}
+
+func CreatePlayer(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	player := model.Player{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&player); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	// New player is the last fallback
+	if player.Priority == 0 {
+		var count int
+		db.Model(&model.Player{}).Where("show_id = ? AND show_type = ?", player.ShowID, player.ShowType).Count(&count)
+		player.Priority = count
+	}
+
+	if err := db.Create(&player).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, player)
+}
+
+func UpdatePlayer(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	player := model.Player{}
+	if err := db.First(&player, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&player); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if err := db.Set("gorm:association_autoupdate", false).Save(&player).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, player)
+}
+
+func DeletePlayer(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	player := model.Player{}
+	if err := db.First(&player, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := db.Delete(&player).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusNoContent, nil, nil)
+}
+
+// ReorderPlayer set player priority following the order of given ids
+func ReorderPlayer(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	request := ReorderRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	tx := db.Begin()
+	for priority, id := range request.IDs {
+		if err := tx.Model(&model.Player{}).Where("id = ?", id).Update("priority", priority).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	players := []model.Player{}
+	db.Where("id IN (?)", request.IDs).Order("priority").Find(&players)
+	respondJSON(w, http.StatusOK, nil, players)
+}
+
+// enabledPlayers only include enabled and working player, ordered by priority
+func enabledPlayers(db *gorm.DB) *gorm.DB {
+	return workingLinks(db).Where("enabled = ?", true).Order("priority")
+}
+
 // getPlayerOr404 gets a instance if exists, or respond the 404 error otherwise
 func getPlayerOr404(db *gorm.DB, id int64, playerType string, w http.ResponseWriter, r *http.Request) *PlayerResult {
 	result := PlayerResult{}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+const maxProfilePerUser = 5
+
+func GetAllProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	user := getUserOr404(db, w, r)
+	if user == nil {
+		return
+	}
+
+	profiles := []model.Profile{}
+	if err := db.Where("user_id = ?", user.ID).Preload("Avatar").Find(&profiles).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, profiles)
+}
+
+func CreateProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	user := getUserOr404(db, w, r)
+	if user == nil {
+		return
+	}
+
+	profile := model.Profile{}
+	if err := decodeProfile(r.Body, &profile); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if !isValidMaxRating(db, user.RatingCountry, profile.MaxRating) {
+		respondError(w, http.StatusBadRequest, "max_rating is not a known rating")
+		return
+	}
+
+	var count int
+	db.Model(&model.Profile{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= maxProfilePerUser {
+		respondError(w, http.StatusBadRequest, "maximum number of profile reached")
+		return
+	}
+
+	profile.UserID = int(user.ID)
+	if err := db.Set("gorm:association_autoupdate", false).Create(&profile).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, profile)
+}
+
+func UpdateProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	profile := getProfileOr404(db, w, r)
+	if profile == nil {
+		return
+	}
+
+	if err := decodeProfile(r.Body, profile); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	user := model.User{}
+	db.First(&user, profile.UserID)
+	if !isValidMaxRating(db, user.RatingCountry, profile.MaxRating) {
+		respondError(w, http.StatusBadRequest, "max_rating is not a known rating")
+		return
+	}
+
+	if err := db.Set("gorm:association_autoupdate", false).Save(&profile).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	db.Preload("Avatar").First(profile, profile.ID)
+	respondJSON(w, http.StatusOK, nil, profile)
+}
+
+func DeleteProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	profile := getProfileOr404(db, w, r)
+	if profile == nil {
+		return
+	}
+	if err := db.Delete(&profile).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusNoContent, nil, nil)
+}
+
+// SelectProfile issue a new token carrying the profile.
+// Client can also send X-Profile-ID header instead
+func SelectProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	profile := getProfileOr404(db, w, r)
+	if profile == nil {
+		return
+	}
+
+	user := model.User{}
+	if err := db.First(&user, profile.UserID).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	token, err := createToken(user, profile.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, map[string]interface{}{"token": token, "profile": profile})
+}
+
+// profileRequest is the editable fields of a profile, id and owner are never taken from the body
+type profileRequest struct {
+	Name      string `json:"name"`
+	AvatarID  int    `json:"avatar_id"`
+	Kids      bool   `json:"kids"`
+	Language  string `json:"language"`
+	MaxRating string `json:"max_rating"`
+}
+
+// decodeProfile sets the editable fields of profile from the body, missing field keeps its value
+func decodeProfile(body io.Reader, profile *model.Profile) error {
+	request := profileRequest{
+		Name:      profile.Name,
+		AvatarID:  profile.AvatarID,
+		Kids:      profile.Kids,
+		Language:  profile.Language,
+		MaxRating: profile.MaxRating,
+	}
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		return err
+	}
+
+	profile.Name = request.Name
+	profile.AvatarID = request.AvatarID
+	profile.Kids = request.Kids
+	profile.Language = request.Language
+	profile.MaxRating = request.MaxRating
+	return nil
+}
+
+// getActiveProfile returns profile selected by logged in user, nil when none selected
+func getActiveProfile(db *gorm.DB, r *http.Request) *model.Profile {
+	profileID := r.Header.Get("profile")
+	username := r.Header.Get("username")
+	if len(profileID) == 0 || len(username) == 0 {
+		return nil
+	}
+
+	profile := model.Profile{}
+	if err := db.
+		Joins("JOIN users ON users.id = profiles.user_id AND users.deleted_at IS NULL").
+		Where("profiles.id = ? AND users.username = ?", profileID, username).
+		First(&profile).Error; err != nil {
+		return nil
+	}
+	return &profile
+}
+
+// getUserOr404 gets logged in user, or respond the 404 error otherwise
+func getUserOr404(db *gorm.DB, w http.ResponseWriter, r *http.Request) *model.User {
+	user := model.User{}
+	if err := db.Where("username = ?", r.Header.Get("username")).First(&user).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return &user
+}
+
+// getProfileOr404 gets profile of logged in user, or respond the 404 error otherwise
+func getProfileOr404(db *gorm.DB, w http.ResponseWriter, r *http.Request) *model.Profile {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	profile := model.Profile{}
+	if err := db.
+		Joins("JOIN users ON users.id = profiles.user_id AND users.deleted_at IS NULL").
+		Where("users.username = ?", r.Header.Get("username")).
+		Preload("Avatar").
+		First(&profile, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return &profile
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+)
+
+func TestDecodeProfileKeepsOwner(t *testing.T) {
+	profile := model.Profile{UserID: 7, Name: "Kids", Kids: true, MaxRating: "PG"}
+	profile.ID = 3
+
+	body := `{"id": 99, "ID": 99, "user_id": 1, "UserID": 1, "name": "Adult", "kids": false}`
+	if err := decodeProfile(strings.NewReader(body), &profile); err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.ID != 3 || profile.UserID != 7 {
+		t.Errorf("profile moved to id %d of user %d, want id 3 of user 7", profile.ID, profile.UserID)
+	}
+	if profile.Name != "Adult" || profile.Kids {
+		t.Errorf("editable fields not changed: %+v", profile)
+	}
+	if profile.MaxRating != "PG" {
+		t.Errorf("max rating %q, missing field should keep PG", profile.MaxRating)
+	}
+}
+
+func TestDecodeProfileInvalidBody(t *testing.T) {
+	profile := model.Profile{Name: "Kids"}
+	if err := decodeProfile(strings.NewReader(`{"name":`), &profile); err == nil {
+		t.Error("invalid body decoded without error")
+	}
+	if profile.Name != "Kids" {
+		t.Errorf("name %q changed by invalid body", profile.Name)
+	}
+}
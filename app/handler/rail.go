@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+type ReorderRequest struct {
+	IDs []int `json:"ids"`
+}
+
+func GetAllRail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	rails := []model.Rail{}
+	if err := db.
+		Order("position").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Find(&rails).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, rails)
+}
+
+func CreateRail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	rail := model.Rail{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&rail); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	// New rail is placed at the bottom of home screen
+	if rail.Position == 0 {
+		var count int
+		db.Model(&model.Rail{}).Count(&count)
+		rail.Position = count
+	}
+
+	if err := db.Save(&rail).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, rail)
+}
+
+func GetRail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	rail := getRailOr404(db, id, w, r)
+	if rail == nil {
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, rail)
+}
+
+func UpdateRail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	rail := getRailOr404(db, id, w, r)
+	if rail == nil {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&rail); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if err := db.Save(&rail).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	db.Model(&rail).Association("Items").Replace(rail.Items)
+
+	respondJSON(w, http.StatusOK, nil, rail)
+}
+
+func DeleteRail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	rail := getRailOr404(db, id, w, r)
+	if rail == nil {
+		return
+	}
+	if err := db.Delete(&rail).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusNoContent, nil, nil)
+}
+
+// ReorderRail set rail position following the order of given ids
+func ReorderRail(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	request := ReorderRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	tx := db.Begin()
+	for position, id := range request.IDs {
+		if err := tx.Model(&model.Rail{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	GetAllRail(db, w, r)
+}
+
+// getRailOr404 gets a instance if exists, or respond the 404 error otherwise
+func getRailOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Request) *model.Rail {
+	rail := model.Rail{}
+	if err := db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		First(&rail, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return &rail
+}
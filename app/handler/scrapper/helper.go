@@ -2,8 +2,11 @@ package scrapper
 
 import (
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
+
+	"github.com/condrowiyono/ruangtengah-api/app/cache"
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+	"github.com/gorilla/mux"
 )
 
 type Response struct {
@@ -20,23 +23,24 @@ type Meta struct {
 	Total  int64 `json:"total"`
 }
 
-var err error
-
-func getHTTPRequestGetBody(url string) ([]byte, error) {
-	resp, err := http.Get(url)
-
-	if err != nil {
-		return nil, err
+// getProviderOr404 returns the provider of the URL, ex: tmdb of /partner/tmdb/search, or respond the 404 error otherwise.
+// Cache status of its calls is sent in the X-Cache header
+func getProviderOr404(providers provider.Providers, w http.ResponseWriter, r *http.Request) provider.Provider {
+	name := mux.Vars(r)["provider"]
+	source, ok := providers[name]
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown provider "+name)
+		return nil
 	}
+	return provider.Track(source, cache.NewTracker(w.Header()))
+}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-
-	if err != nil {
-		return nil, err
+// getTitleID returns id of the title on the provider, tmdb is kept for the old clients
+func getTitleID(r *http.Request) string {
+	if id := getHTTPRequestQuery(r, "id"); len(id) != 0 {
+		return id
 	}
-
-	return body, nil
+	return getHTTPRequestQuery(r, "tmdb")
 }
 
 func getHTTPRequestQuery(r *http.Request, query string) string {
@@ -0,0 +1,43 @@
+package scrapper
+
+import (
+	"net/http"
+
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+)
+
+// SearchPerson search people by name on the provider
+func SearchPerson(providers provider.Providers, w http.ResponseWriter, r *http.Request) {
+	source := getProviderOr404(providers, w, r)
+	if source == nil {
+		return
+	}
+
+	query := getHTTPRequestQuery(r, "query")
+	if len(query) == 0 {
+		respondError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	results, err := source.SearchPerson(query)
+	if err != nil {
+		respondError(w, provider.HTTPStatus(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, results)
+}
+
+// GetPersonDetail get person detail from the provider
+func GetPersonDetail(providers provider.Providers, w http.ResponseWriter, r *http.Request) {
+	source := getProviderOr404(providers, w, r)
+	if source == nil {
+		return
+	}
+
+	person, err := source.Person(getTitleID(r))
+	if err != nil {
+		respondError(w, provider.HTTPStatus(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, person)
+}
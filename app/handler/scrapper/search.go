@@ -1,28 +1,17 @@
 package scrapper
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"net/url"
-	"os"
-)
 
-// TMDBSearch type
-type TMDBSearch struct {
-	Results []struct {
-		PosterPath  string `json:"poster_path"`
-		BannerPath  string `json:"backdrop_path"`
-		ID          int    `json:"id"`
-		Title       string `json:"title"`
-		Name        string `json:"name,omitempty"`
-		ReleaseDate string `json:"release_date,omitempty"`
-	} `json:"results"`
-}
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+)
 
-// SearchMovie Method
-func SearchMovie(w http.ResponseWriter, r *http.Request) {
-	var tmdbSearch TMDBSearch
+// SearchMovie search movie or tv on the provider, non empty year narrows down the result
+func SearchMovie(providers provider.Providers, w http.ResponseWriter, r *http.Request) {
+	source := getProviderOr404(providers, w, r)
+	if source == nil {
+		return
+	}
 
 	query := getHTTPRequestQuery(r, "query")
 	typeName := getHTTPRequestQuery(r, "type") // movie or tv
@@ -31,14 +20,10 @@ func SearchMovie(w http.ResponseWriter, r *http.Request) {
 		typeName = "movie"
 	}
 
-	url := fmt.Sprintf(
-		"https://api.themoviedb.org/3/search/%s?api_key=%s&query=%s",
-		typeName,
-		os.Getenv("TMDB_KEY"),
-		url.PathEscape(query),
-	)
-
-	body, _ := getHTTPRequestGetBody(url)
-	err = json.Unmarshal(body, &tmdbSearch)
-	respondJSON(w, http.StatusOK, nil, tmdbSearch.Results)
+	results, err := source.Search(typeName, query, getHTTPRequestQuery(r, "year"))
+	if err != nil {
+		respondError(w, provider.HTTPStatus(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, results)
 }
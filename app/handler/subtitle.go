@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
+	"github.com/condrowiyono/ruangtengah-api/app/subtitle"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+const subtitleDir = "subtitles"
+
+type SubtitleRequest struct {
+	Language string `json:"language"`
+	Label    string `json:"label"`
+	Default  bool   `json:"default"`
+	Forced   bool   `json:"forced"`
+	Offset   int    `json:"offset"`
+}
+
+// UploadSubtitle accept SRT, ASS or WebVTT file and store it as WebVTT track of the player.
+// Offset is in millisecond, negative value show the text earlier
+func UploadSubtitle(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	playerID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	player := model.Player{}
+	if err := db.First(&player, playerID).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	r.ParseMultipartForm(10 << 20)
+
+	file, handler, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	fileBytes, err := ioutil.ReadAll(file)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.FormValue("offset"))
+	format := subtitle.DetectFormat(handler.Filename, fileBytes)
+	vtt, err := subtitle.ToWebVTT(fileBytes, format, time.Duration(offset)*time.Millisecond)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key := storage.NewKey(subtitleDir, "subtitle", ".vtt")
+	if err := store.Put(key, bytes.NewReader(vtt), "text/vtt"); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	track := model.Subtitle{
+		PlayerID: int(player.ID),
+		Language: r.FormValue("language"),
+		Label:    r.FormValue("label"),
+		Default:  r.FormValue("default") == "true",
+		Forced:   r.FormValue("forced") == "true",
+		Path:     key,
+		URL:      store.URL(key),
+	}
+	if len(track.Label) == 0 {
+		track.Label = track.Language
+	}
+
+	if err := saveSubtitle(db, &track); err != nil {
+		store.Delete(key)
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, nil, track)
+}
+
+// UpdateSubtitle change track detail, non zero offset shift the stored file
+func UpdateSubtitle(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	track := getSubtitleOr404(db, id, w, r)
+	if track == nil {
+		return
+	}
+
+	request := SubtitleRequest{
+		Language: track.Language,
+		Label:    track.Label,
+		Default:  track.Default,
+		Forced:   track.Forced,
+	}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if request.Offset != 0 {
+		file, err := store.Get(subtitleKey(track))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		fileBytes, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		vtt, err := subtitle.ToWebVTT(fileBytes, subtitle.FormatVTT, time.Duration(request.Offset)*time.Millisecond)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := store.Put(subtitleKey(track), bytes.NewReader(vtt), "text/vtt"); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	track.Language = request.Language
+	track.Label = request.Label
+	track.Default = request.Default
+	track.Forced = request.Forced
+	if err := saveSubtitle(db, track); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, nil, track)
+}
+
+func DeleteSubtitle(db *gorm.DB, store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	track := getSubtitleOr404(db, id, w, r)
+	if track == nil {
+		return
+	}
+	if err := db.Delete(&track).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	store.Delete(subtitleKey(track))
+	respondJSON(w, http.StatusNoContent, nil, nil)
+}
+
+// saveSubtitle save the track, only one default track is kept per player
+func saveSubtitle(db *gorm.DB, track *model.Subtitle) error {
+	tx := db.Begin()
+	if track.Default {
+		if err := tx.Model(&model.Subtitle{}).
+			Where("player_id = ? AND id <> ?", track.PlayerID, track.ID).
+			Update("is_default", false).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Save(track).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// subtitleKey returns storage key of the track, path of track uploaded before the storage includes the local folder
+func subtitleKey(track *model.Subtitle) string {
+	return strings.TrimPrefix(track.Path, "uploads/")
+}
+
+// getSubtitleOr404 gets a instance if exists, or respond the 404 error otherwise
+func getSubtitleOr404(db *gorm.DB, id int64, w http.ResponseWriter, r *http.Request) *model.Subtitle {
+	track := model.Subtitle{}
+	if err := db.First(&track, id).Error; err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return &track
+}
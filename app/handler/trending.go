@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/jinzhu/gorm"
+)
+
+func GetTrending(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	vars := r.URL.Query()
+
+	period := string(vars.Get("window"))
+	if len(period) == 0 {
+		period = model.TrendingWeek
+	}
+	if period != model.TrendingDay && period != model.TrendingWeek {
+		respondError(w, http.StatusBadRequest, "window must be day or week")
+		return
+	}
+
+	limit := string(vars.Get("limit"))
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		limitInt = 25
+	}
+
+	query := db.Where("period = ?", period)
+
+	kind := string(vars.Get("kind"))
+	if len(kind) != 0 {
+		query = query.Where("show_type = ?", showTable(kind))
+	}
+
+	scores := []model.TrendingScore{}
+	if err := query.Order("score DESC").Limit(limitInt).Find(&scores).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ageLimit := getAgeLimit(db, r)
+	trending := []model.TrendingScore{}
+	for _, score := range scores {
+		score.Show = findShow(db, score.ShowType, score.ShowID, ageLimit)
+		if score.Show != nil {
+			trending = append(trending, score)
+		}
+	}
+	respondJSON(w, http.StatusOK, nil, trending)
+}
+
+// sortByTrending orders a show query by its trending score of the period
+func sortByTrending(query *gorm.DB, table string, period string) *gorm.DB {
+	return query.
+		Joins("LEFT JOIN trending_scores ON (trending_scores.show_id = "+table+".id AND trending_scores.show_type = ? AND trending_scores.period = ? AND trending_scores.deleted_at IS NULL)", table, period).
+		Order("trending_scores.score DESC")
+}
@@ -0,0 +1,159 @@
+package healthcheck
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// brokenAfter is how many checks in a row must fail before the source is hidden as broken,
+// so a single timeout doesn't take it down until the next check
+const brokenAfter = 3
+
+// link is a source URL of a table row
+type link struct {
+	ID  uint
+	URL string
+}
+
+// result is outcome of probing a link
+type result struct {
+	Status  int
+	Latency time.Duration
+	Err     error
+}
+
+// Checker probes every player and video URL and records whether it still works
+type Checker struct {
+	db          *gorm.DB
+	client      *http.Client
+	concurrency int
+}
+
+// NewChecker create checker running concurrency probes at once, each limited by timeout
+func NewChecker(db *gorm.DB, concurrency int, timeout time.Duration) *Checker {
+	return &Checker{
+		db:          db,
+		client:      &http.Client{Timeout: timeout},
+		concurrency: concurrency,
+	}
+}
+
+// Run checks every link periodically
+func (c *Checker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		c.CheckAll()
+	}
+}
+
+// CheckAll checks players then videos
+func (c *Checker) CheckAll() {
+	if err := c.Check("players", "player_url"); err != nil {
+		log.Println("healthcheck: players", err)
+	}
+	if err := c.Check("videos", "video_url"); err != nil {
+		log.Println("healthcheck: videos", err)
+	}
+}
+
+// Check probes URL column of every row in table and store the result on the row
+func (c *Checker) Check(table string, column string) error {
+	links := []link{}
+	if err := c.db.Table(table).
+		Select("id, " + column + " AS url").
+		Where("deleted_at IS NULL").
+		Scan(&links).Error; err != nil {
+		return err
+	}
+
+	queue := make(chan link)
+	var wg sync.WaitGroup
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l := range queue {
+				c.save(table, l, c.probe(l.URL))
+			}
+		}()
+	}
+
+	for _, l := range links {
+		// Non http source like a youtube key is not checkable
+		if !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
+			continue
+		}
+		queue <- l
+	}
+	close(queue)
+	wg.Wait()
+	return nil
+}
+
+// probe sends HEAD request, server which doesn't support it is retried with GET of the first byte
+func (c *Checker) probe(url string) result {
+	start := time.Now()
+	status, err := c.request(http.MethodHead, url)
+	if err != nil || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented || status == http.StatusForbidden {
+		start = time.Now()
+		status, err = c.request(http.MethodGet, url)
+	}
+
+	res := result{Status: status, Latency: time.Since(start), Err: err}
+	if err == nil && status >= http.StatusBadRequest {
+		res.Err = errors.New(http.StatusText(status))
+	}
+	return res
+}
+
+func (c *Checker) request(method string, url string) (int, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+// save store probe result without touching updated_at.
+// Success clears the failures, the row is broken once brokenAfter checks in a row failed
+func (c *Checker) save(table string, l link, res result) {
+	columns := map[string]interface{}{
+		"link_status":     res.Status,
+		"link_latency":    int(res.Latency / time.Millisecond),
+		"link_error":      "",
+		"link_checked_at": time.Now(),
+		"link_failures":   0,
+		"broken":          false,
+	}
+	if res.Err != nil {
+		columns["link_error"] = res.Err.Error()
+		columns["link_failures"] = gorm.Expr("COALESCE(link_failures, 0) + 1")
+		delete(columns, "broken")
+	}
+
+	row := c.db.Table(table).Where("id = ?", l.ID)
+	err := row.UpdateColumns(columns).Error
+	if err == nil && res.Err != nil {
+		err = row.UpdateColumn("broken", gorm.Expr("link_failures >= ?", brokenAfter)).Error
+	}
+	if err != nil {
+		log.Println("healthcheck: save", table, l.ID, err)
+	}
+}
@@ -0,0 +1,103 @@
+package imagesearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/condrowiyono/ruangtengah-api/app/outbound"
+)
+
+var (
+	duckDuckGoVQD   = regexp.MustCompile(`vqd=(.*?)&`)
+	duckDuckGoQuery = regexp.MustCompile(`q=(.*?)&`)
+)
+
+// DuckDuckGo searches images on DuckDuckGo, its result has the size
+type DuckDuckGo struct {
+	client *http.Client
+}
+
+// NewDuckDuckGo create DuckDuckGo source
+func NewDuckDuckGo() *DuckDuckGo {
+	limiter := outbound.Shared("duckduckgo", os.Getenv("IMAGE_SEARCH_RATE_LIMIT"))
+	return &DuckDuckGo{client: outbound.NewClient(outbound.OptionsFromEnv(limiter))}
+}
+
+func (d *DuckDuckGo) Name() string {
+	return "duckduckgo"
+}
+
+// Search reads the token of the search page first, the image API needs it
+func (d *DuckDuckGo) Search(query Query) ([]Result, error) {
+	// Request the HTML page.
+	duckduckGoURL := fmt.Sprintf("https://duckduckgo.com/?q=%s&iar=images&iax=images&ia=images", url.QueryEscape(query.Text))
+	res, err := d.client.Get(duckduckGoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := outbound.CheckStatus(res); err != nil {
+		return nil, err
+	}
+
+	var vqd string
+	var q string
+	// Load the HTML document
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Find VQD and Query
+	doc.Find("body script").Each(func(i int, sc *goquery.Selection) {
+		js := sc.Text()
+		if found := duckDuckGoVQD.FindStringSubmatch(js); len(found) > 1 {
+			vqd = found[1]
+		}
+		if found := duckDuckGoQuery.FindStringSubmatch(js); len(found) > 1 {
+			q = found[1]
+		}
+	})
+	if len(vqd) == 0 {
+		return nil, errors.New("duckduckgo: search token not found")
+	}
+
+	// Lets find DuckduckGO Image
+	duckduckGoJSONImage := fmt.Sprintf("https://duckduckgo.com/i.js?l=us-en&o=json&q=%s&vqd=%s&f=,,,&p=1&v7exp=a", q, vqd)
+	resp, err := d.client.Get(duckduckGoJSONImage)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := outbound.CheckStatus(resp); err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := struct {
+		Results []struct {
+			Height    int    `json:"height"`
+			Width     int    `json:"width"`
+			Thumbnail string `json:"thumbnail"`
+			Image     string `json:"image"`
+		} `json:"results"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("duckduckgo: %v", err)
+	}
+
+	results := []Result{}
+	for _, v := range response.Results {
+		results = append(results, Result{Thumbnail: v.Thumbnail, Image: v.Image, Width: v.Width, Height: v.Height})
+	}
+	return results, nil
+}
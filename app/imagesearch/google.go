@@ -0,0 +1,79 @@
+package imagesearch
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/condrowiyono/ruangtengah-api/app/outbound"
+)
+
+var (
+	// googleSizedImage is an image in the page data, followed by its height and width
+	googleSizedImage = regexp.MustCompile(`\["(https?://[^"]+?\.(?:jpg|jpeg|png|gif|webp)[^"]*)",(\d+),(\d+)\]`)
+	googleImage      = regexp.MustCompile(`(http(s?):)([/|.|\w|\s|-])*\.(?:jpg|gif|png)`)
+)
+
+// Google scrapes the image search page, size is known only when the page data has it
+type Google struct {
+	client *http.Client
+}
+
+// NewGoogle create Google source
+func NewGoogle() *Google {
+	limiter := outbound.Shared("google", os.Getenv("IMAGE_SEARCH_RATE_LIMIT"))
+	return &Google{client: outbound.NewClient(outbound.OptionsFromEnv(limiter))}
+}
+
+func (g *Google) Name() string {
+	return "google"
+}
+
+func (g *Google) Search(query Query) ([]Result, error) {
+	// Request the HTML page.
+	googleURL := fmt.Sprintf("https://www.google.co.id/search?q=%s&source=lnms&tbm=isch", url.QueryEscape(query.Text))
+
+	req, err := http.NewRequest("GET", googleURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_3) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.116 Safari/537.36 Edg/80.0.361.57")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := outbound.CheckStatus(resp); err != nil {
+		return nil, err
+	}
+
+	// Load the HTML document
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	doc.Find("body script").Each(func(i int, sc *goquery.Selection) {
+		js := sc.Text()
+		if sized := googleSizedImage.FindAllStringSubmatch(js, -1); len(sized) > 0 {
+			for _, found := range sized {
+				height, _ := strconv.Atoi(found[2])
+				width, _ := strconv.Atoi(found[3])
+				results = append(results, Result{Thumbnail: found[1], Image: found[1], Width: width, Height: height})
+			}
+			return
+		}
+		for _, found := range googleImage.FindAllString(js, -1) {
+			results = append(results, Result{Thumbnail: found, Image: found})
+		}
+	})
+	return results, nil
+}
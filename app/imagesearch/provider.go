@@ -0,0 +1,70 @@
+package imagesearch
+
+import (
+	"strconv"
+
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+)
+
+// ProviderSource finds images of a title on a metadata provider.
+// Title without id of the provider is searched by text first
+type ProviderSource struct {
+	provider provider.Provider
+}
+
+// NewProviderSource create source of the provider
+func NewProviderSource(source provider.Provider) *ProviderSource {
+	return &ProviderSource{provider: source}
+}
+
+func (p *ProviderSource) Name() string {
+	return p.provider.Name()
+}
+
+func (p *ProviderSource) Search(query Query) ([]Result, error) {
+	kind := query.Kind
+	if len(kind) == 0 {
+		kind = "movie"
+	}
+
+	id := query.IDs[p.provider.Name()]
+	if len(id) == 0 {
+		titles, err := p.provider.Search(kind, query.Text, query.Year)
+		if err != nil || len(titles) == 0 {
+			return nil, err
+		}
+		id = providerID(titles[0])
+	}
+
+	images, err := p.provider.Images(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []provider.Image{}
+	if query.Role != RoleBanner {
+		found = append(found, images.Posters...)
+	}
+	if query.Role != RolePoster {
+		found = append(found, images.Backdrops...)
+	}
+
+	results := []Result{}
+	for _, image := range found {
+		results = append(results, Result{
+			Thumbnail: image.Thumbnail,
+			Image:     image.Image,
+			Width:     image.Width,
+			Height:    image.Height,
+		})
+	}
+	return results, nil
+}
+
+// providerID is the IMDb id for provider like OMDb, TMDB id otherwise
+func providerID(title provider.SearchResult) string {
+	if title.ID == 0 {
+		return title.ImdbID
+	}
+	return strconv.Itoa(title.ID)
+}
@@ -0,0 +1,278 @@
+package imagesearch
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/cache"
+	"github.com/condrowiyono/ruangtengah-api/app/outbound"
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+)
+
+// Role of the searched image, it decides the best aspect ratio
+const (
+	RolePoster = "poster"
+	RoleBanner = "banner"
+)
+
+// ErrTimeout is reported for a source not answering in time
+var ErrTimeout = errors.New("timeout")
+
+// aspectRatios are the ideal width / height by role
+var aspectRatios = map[string]float64{
+	RolePoster: 2.0 / 3.0,
+	RoleBanner: 16.0 / 9.0,
+}
+
+// Query of images, IDs are id of the title by provider name, ex: tmdb: 27205.
+// Kind is movie or tv, Role is poster, banner or empty for both
+type Query struct {
+	Text string
+	Year string
+	Kind string
+	Role string
+	IDs  map[string]string
+}
+
+// Result is an image found by a source, size is zero when unknown
+type Result struct {
+	Thumbnail string  `json:"thumbnail"`
+	Image     string  `json:"image"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Source    string  `json:"source"`
+	Score     float64 `json:"score"` // lower is better
+}
+
+// Source finds images, ex: TMDB, DuckDuckGo or Google
+type Source interface {
+	Name() string
+	Search(query Query) ([]Result, error)
+}
+
+// SourceStatus tells how a source answered
+type SourceStatus struct {
+	Name    string `json:"name"`
+	Count   int    `json:"count"`
+	Took    int64  `json:"took_ms"`
+	Error   string `json:"error,omitempty"`
+	Timeout bool   `json:"timeout,omitempty"`
+	Cache   string `json:"cache,omitempty"`
+}
+
+// Searcher asks every source at once, earlier source wins duplicate results
+type Searcher struct {
+	sources []Source
+	timeout time.Duration
+	cache   *cache.Cache
+	ttls    cache.TTLs
+}
+
+// NewSearcher create searcher, source not answering in timeout is skipped
+func NewSearcher(sources []Source, timeout time.Duration) *Searcher {
+	return &Searcher{sources: sources, timeout: timeout}
+}
+
+// WithCache keeps results of every source in the cache for the image-search TTL,
+// provider source relies on the cache of the provider instead
+func (s *Searcher) WithCache(c *cache.Cache, ttls cache.TTLs) *Searcher {
+	s.cache = c
+	s.ttls = ttls
+	return s
+}
+
+// FromEnv create searcher of IMAGE_SEARCH_SOURCES (comma separated, default tmdb,duckduckgo,google),
+// a provider name makes it a source. IMAGE_SEARCH_TIMEOUT is the time given to each source, 5s by default.
+// IMAGE_SEARCH_RATE_LIMIT limits requests to each of DuckDuckGo and Google, ex: 30/1m
+func FromEnv(providers provider.Providers) *Searcher {
+	names := os.Getenv("IMAGE_SEARCH_SOURCES")
+	if len(names) == 0 {
+		names = "tmdb,duckduckgo,google"
+	}
+
+	sources := []Source{}
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "duckduckgo":
+			sources = append(sources, NewDuckDuckGo())
+		case "google":
+			sources = append(sources, NewGoogle())
+		default:
+			if source, ok := providers[name]; ok {
+				sources = append(sources, NewProviderSource(source))
+			}
+		}
+	}
+
+	timeout, err := time.ParseDuration(os.Getenv("IMAGE_SEARCH_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return NewSearcher(sources, timeout)
+}
+
+// Search returns deduplicated results of every source ranked by their fitness to the role,
+// with the status of every source
+func (s *Searcher) Search(query Query) ([]Result, []SourceStatus) {
+	// Buffered so late source doesn't block after the timeout
+	answers := make([]chan answer, len(s.sources))
+	for i, source := range s.sources {
+		answers[i] = make(chan answer, 1)
+		go func(source Source, answers chan answer) {
+			start := time.Now()
+			results, status, err := s.search(source, query)
+			answers <- answer{results, status, err, time.Since(start)}
+		}(source, answers[i])
+	}
+
+	// Every source started at the same time so they share the deadline
+	deadline := time.Now().Add(s.timeout)
+	merged := []Result{}
+	statuses := []SourceStatus{}
+	for i, source := range s.sources {
+		status := SourceStatus{Name: source.Name()}
+		reply, ok := wait(answers[i], deadline)
+		if !ok {
+			status.Took = int64(s.timeout / time.Millisecond)
+			status.Error = ErrTimeout.Error()
+			status.Timeout = true
+			statuses = append(statuses, status)
+			continue
+		}
+
+		status.Took = int64(reply.took / time.Millisecond)
+		status.Cache = reply.cache
+		if reply.err != nil {
+			status.Error = reply.err.Error()
+			status.Timeout = outbound.IsTimeout(reply.err)
+		}
+		for _, result := range reply.results {
+			result.Source = source.Name()
+			merged = append(merged, result)
+		}
+		status.Count = len(reply.results)
+		statuses = append(statuses, status)
+	}
+
+	results := dedup(merged)
+	rank(results, query.Role)
+	return results, statuses
+}
+
+type answer struct {
+	results []Result
+	cache   string
+	err     error
+	took    time.Duration
+}
+
+// search asks the source unless its results are cached, it returns the cache status as well
+func (s *Searcher) search(source Source, query Query) ([]Result, string, error) {
+	if s.cache == nil {
+		results, err := source.Search(query)
+		return results, "", err
+	}
+
+	if p, ok := source.(*ProviderSource); ok {
+		header := http.Header{}
+		results, err := NewProviderSource(provider.Track(p.provider, cache.NewTracker(header))).Search(query)
+		return results, header.Get(cache.Header), err
+	}
+
+	// Map of IDs is marshaled with sorted keys so the same query has the same key
+	key, err := json.Marshal(query)
+	if err != nil {
+		return nil, "", err
+	}
+	results := []Result{}
+	status, err := s.cache.Load("image-search:"+source.Name()+":"+string(key), "image-search", s.ttls, &results, func() (interface{}, error) {
+		return source.Search(query)
+	})
+	return results, status, err
+}
+
+// wait returns the answer unless the deadline passes first, answer already there always wins
+func wait(answers chan answer, deadline time.Time) (answer, bool) {
+	select {
+	case a := <-answers:
+		return a, true
+	default:
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case a := <-answers:
+		return a, true
+	case <-timer.C:
+		return answer{}, false
+	}
+}
+
+// dedup removes the same image found again, missing size is taken from the duplicate
+func dedup(results []Result) []Result {
+	unique := []Result{}
+	seen := map[string]int{}
+	for _, result := range results {
+		key := imageKey(result.Image)
+		if len(key) == 0 {
+			continue
+		}
+		if i, ok := seen[key]; ok {
+			if unique[i].Width == 0 || unique[i].Height == 0 {
+				unique[i].Width, unique[i].Height = result.Width, result.Height
+			}
+			continue
+		}
+		seen[key] = len(unique)
+		unique = append(unique, result)
+	}
+	return unique
+}
+
+// imageKey is the URL without scheme and www, the same file is often linked both ways
+func imageKey(image string) string {
+	parsed, err := url.Parse(strings.TrimSpace(image))
+	if err != nil || len(parsed.Host) == 0 {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	return host + parsed.EscapedPath() + "?" + parsed.RawQuery
+}
+
+// rank sorts by score, then by the bigger image. Source order is kept otherwise
+func rank(results []Result, role string) {
+	for i := range results {
+		results[i].Score = score(results[i], role)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score < results[j].Score
+		}
+		return results[i].Width*results[i].Height > results[j].Width*results[j].Height
+	})
+}
+
+// score is how far the aspect ratio is from the ideal one of the role, in log scale so 2x wider
+// is as bad as 2x taller. Small image is penalized, unknown size is ranked after a fair match
+func score(result Result, role string) float64 {
+	if result.Width <= 0 || result.Height <= 0 {
+		return 1
+	}
+
+	value := 0.0
+	if ideal, ok := aspectRatios[role]; ok {
+		value = math.Abs(math.Log(float64(result.Width) / float64(result.Height) / ideal))
+	}
+	if result.Width < 300 && result.Height < 300 {
+		value += 0.5
+	}
+	return math.Round(value*1000) / 1000
+}
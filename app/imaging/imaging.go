@@ -0,0 +1,202 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+// Supported upload formats, as named by image.Decode
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// OriginalRendition is the name of the full size image
+const OriginalRendition = "original"
+
+// MaxPixels limit the decoded size, to refuse decompression bomb
+const MaxPixels = 50 * 1000 * 1000
+
+const jpegQuality = 85
+
+var (
+	// ErrUnsupported is returned when the file is not an image of supported format
+	ErrUnsupported = errors.New("unsupported image, only JPEG, PNG, WebP and GIF are allowed")
+	// ErrTooLarge is returned when the image has too many pixels
+	ErrTooLarge = errors.New("image is too large")
+
+	defaultRenditions = []Rendition{{Name: "thumbnail", Width: 185}, {Name: "w500", Width: 500}}
+)
+
+// Rendition is a resized copy of the image, height follows the aspect ratio
+type Rendition struct {
+	Name  string
+	Width int
+}
+
+// Output is an encoded image ready to be stored
+type Output struct {
+	Name     string
+	Width    int
+	Height   int
+	MimeType string
+	Ext      string
+	Data     []byte
+}
+
+// Result is the processed upload, the original comes first in Outputs
+type Result struct {
+	Format  string
+	Width   int
+	Height  int
+	PHash   uint64
+	Outputs []Output
+
+	Placeholder
+}
+
+// RenditionsFromEnv read IMAGE_RENDITIONS, ex: "thumbnail:185,w500:500"
+func RenditionsFromEnv() []Rendition {
+	value := os.Getenv("IMAGE_RENDITIONS")
+	if len(value) == 0 {
+		return defaultRenditions
+	}
+
+	renditions := []Rendition{}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		width, err := strconv.Atoi(parts[1])
+		if err != nil || width <= 0 || parts[0] == OriginalRendition {
+			continue
+		}
+		renditions = append(renditions, Rendition{Name: parts[0], Width: width})
+	}
+	return renditions
+}
+
+// Process validates and decodes the upload, then re-encodes the original and every rendition.
+// Re-encoding drops EXIF and other metadata, JPEG orientation is applied before that
+func Process(data []byte, renditions []Rendition) (*Result, error) {
+	img, format, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	result := &Result{
+		Format:      format,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		PHash:       PerceptualHash(img),
+		Placeholder: NewPlaceholder(img),
+	}
+
+	original, err := encode(OriginalRendition, img, format)
+	if err != nil {
+		return nil, err
+	}
+	// GIF has no EXIF, keeping the bytes keeps the animation
+	if format == FormatGIF {
+		original.Data = data
+		original.MimeType = "image/gif"
+		original.Ext = ".gif"
+	}
+	result.Outputs = append(result.Outputs, original)
+
+	for _, rendition := range renditions {
+		output, err := encode(rendition.Name, resize(img, rendition.Width), format)
+		if err != nil {
+			return nil, err
+		}
+		result.Outputs = append(result.Outputs, output)
+	}
+	return result, nil
+}
+
+// Decode validates and decodes image of supported format, JPEG is rotated following its EXIF orientation
+func Decode(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || !isSupported(format) {
+		return nil, "", ErrUnsupported
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+	if format == FormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// resize scales image down to width, smaller image is not enlarged
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// encode JPEG as JPEG, other format as PNG unless it's fully opaque
+func encode(name string, img image.Image, format string) (Output, error) {
+	bounds := img.Bounds()
+	output := Output{Name: name, Width: bounds.Dx(), Height: bounds.Dy()}
+
+	var buffer bytes.Buffer
+	var err error
+	if format == FormatJPEG || (format == FormatWebP && isOpaque(img)) {
+		output.MimeType, output.Ext = "image/jpeg", ".jpg"
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		output.MimeType, output.Ext = "image/png", ".png"
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		return output, fmt.Errorf("encode %s: %v", name, err)
+	}
+	output.Data = buffer.Bytes()
+	return output, nil
+}
+
+func isSupported(format string) bool {
+	switch format {
+	case FormatJPEG, FormatPNG, FormatGIF, FormatWebP:
+		return true
+	}
+	return false
+}
+
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return false
+}
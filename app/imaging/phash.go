@@ -0,0 +1,54 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	phashSize = 32 // image is reduced to phashSize x phashSize before DCT
+	phashBits = 8  // lowest phashBits x phashBits frequencies make the hash
+)
+
+// PerceptualHash returns 64 bit DCT hash of the image, similar images have a small HammingDistance.
+// It survives resize, re-encoding and small color change
+func PerceptualHash(img image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, phashSize, phashSize))
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	coefficients := make([]float64, 0, phashBits*phashBits)
+	for u := 0; u < phashBits; u++ {
+		for v := 0; v < phashBits; v++ {
+			sum := 0.0
+			for x := 0; x < phashSize; x++ {
+				for y := 0; y < phashSize; y++ {
+					sum += float64(gray.GrayAt(x, y).Y) *
+						math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*phashSize)) *
+						math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*phashSize))
+				}
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	sorted := append([]float64{}, coefficients...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, coefficient := range coefficients {
+		if coefficient > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// HammingDistance counts different bits of two hashes
+func HammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
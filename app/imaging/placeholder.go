@@ -0,0 +1,174 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// BlurHash components, 4x3 suits both poster and banner
+const (
+	blurHashX = 4
+	blurHashY = 3
+
+	placeholderSize = 32 // image is reduced before computing placeholder
+	base83Chars     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+// Placeholder is shown by client while the image loads
+type Placeholder struct {
+	BlurHash      string
+	DominantColor string
+	AverageColor  string
+}
+
+// NewPlaceholder computes BlurHash and colors of the image
+func NewPlaceholder(img image.Image) Placeholder {
+	bounds := img.Bounds()
+	width := placeholderSize
+	height := maxInt(1, bounds.Dy()*placeholderSize/maxInt(1, bounds.Dx()))
+	small := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, bounds, draw.Src, nil)
+
+	dominant, average := colors(small)
+	return Placeholder{
+		BlurHash:      blurHash(small, blurHashX, blurHashY),
+		DominantColor: dominant,
+		AverageColor:  average,
+	}
+}
+
+// colors returns the most common color, by 4 bit per channel bucket, and the average color as #rrggbb.
+// Mostly transparent pixel is ignored
+func colors(img *image.NRGBA) (string, string) {
+	type bucket struct{ count, r, g, b int }
+	buckets := map[int]*bucket{}
+	var total bucket
+	var best *bucket
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A < 128 {
+				continue
+			}
+			r, g, b := int(c.R), int(c.G), int(c.B)
+			total.count++
+			total.r, total.g, total.b = total.r+r, total.g+g, total.b+b
+
+			key := (r>>4)<<8 | (g>>4)<<4 | b>>4
+			if buckets[key] == nil {
+				buckets[key] = &bucket{}
+			}
+			current := buckets[key]
+			current.count++
+			current.r, current.g, current.b = current.r+r, current.g+g, current.b+b
+			if best == nil || current.count > best.count {
+				best = current
+			}
+		}
+	}
+
+	if best == nil {
+		return "", ""
+	}
+	hex := func(b *bucket) string {
+		return fmt.Sprintf("#%02x%02x%02x", b.r/b.count, b.g/b.count, b.b/b.count)
+	}
+	return hex(best), hex(&total)
+}
+
+// blurHash encodes the image following https://github.com/woltapp/blurhash
+func blurHash(img *image.NRGBA, xComponents int, yComponents int) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var r, g, b float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					c := img.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+					r += basis * srgbToLinear(c.R)
+					g += basis * srgbToLinear(c.G)
+					b += basis * srgbToLinear(c.B)
+				}
+			}
+
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(base83((xComponents-1)+(yComponents-1)*9, 1))
+
+	ac := factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, factor := range ac {
+			for _, value := range factor {
+				actualMax = math.Max(actualMax, math.Abs(value))
+			}
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash.WriteString(base83(quantisedMax, 1))
+	} else {
+		hash.WriteString(base83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(base83(linearToSrgb(dc[0])<<16+linearToSrgb(dc[1])<<8+linearToSrgb(dc[2]), 4))
+
+	for _, factor := range ac {
+		quantised := [3]int{}
+		for k, value := range factor {
+			quantised[k] = int(math.Max(0, math.Min(18, math.Floor(signPow(value/maxValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(base83(quantised[0]*19*19+quantised[1]*19+quantised[2], 2))
+	}
+	return hash.String()
+}
+
+func base83(value int, length int) string {
+	result := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		result[i-1] = base83Chars[digit]
+	}
+	return string(result)
+}
+
+func srgbToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,109 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// Fit mode of Transform
+const (
+	FitCover   = "cover"   // fill the box, overflow is cropped from the center
+	FitContain = "contain" // fit inside the box, keep aspect ratio
+	FitFill    = "fill"    // stretch to the box
+)
+
+// MaxDimension limit width and height of transformed image
+const MaxDimension = 2000
+
+// ErrInvalidTransform is returned for unknown fit or out of range size
+var ErrInvalidTransform = errors.New("invalid transform, fit must be cover, contain or fill and size between 0 and 2000")
+
+// Transform resizes image into width x height box. Zero width or height follows the aspect ratio.
+// Image is never enlarged beyond its own size
+func Transform(img image.Image, width int, height int, fit string) (image.Image, error) {
+	if width < 0 || height < 0 || width > MaxDimension || height > MaxDimension {
+		return nil, ErrInvalidTransform
+	}
+	if fit == "" {
+		fit = FitCover
+	}
+	if fit != FitCover && fit != FitContain && fit != FitFill {
+		return nil, ErrInvalidTransform
+	}
+
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if width == 0 && height == 0 {
+		return img, nil
+	}
+	if width == 0 {
+		width = sw * height / sh
+	}
+	if height == 0 {
+		height = sh * width / sw
+	}
+
+	// Don't enlarge, shrink the box keeping its ratio instead
+	if width > sw || height > sh {
+		scale := minFloat(float64(sw)/float64(width), float64(sh)/float64(height))
+		width, height = maxInt(1, int(float64(width)*scale)), maxInt(1, int(float64(height)*scale))
+	}
+
+	src := bounds
+	switch fit {
+	case FitCover:
+		// Crop source to the box ratio
+		if sw*height > sh*width {
+			cw := sh * width / height
+			src = image.Rect(bounds.Min.X+(sw-cw)/2, bounds.Min.Y, bounds.Min.X+(sw-cw)/2+cw, bounds.Max.Y)
+		} else {
+			ch := sw * height / width
+			src = image.Rect(bounds.Min.X, bounds.Min.Y+(sh-ch)/2, bounds.Max.X, bounds.Min.Y+(sh-ch)/2+ch)
+		}
+	case FitContain:
+		scale := minFloat(float64(width)/float64(sw), float64(height)/float64(sh))
+		width, height = maxInt(1, int(float64(sw)*scale)), maxInt(1, int(float64(sh)*scale))
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst, nil
+}
+
+// Encode encodes image as jpeg or png. Other format, including webp which has no encoder,
+// is JPEG for opaque image and PNG otherwise. Returns the data and its MIME type
+func Encode(img image.Image, format string) ([]byte, string, error) {
+	if format != FormatJPEG && format != FormatPNG {
+		format = FormatPNG
+		if isOpaque(img) {
+			format = FormatJPEG
+		}
+	}
+
+	var buffer bytes.Buffer
+	if format == FormatJPEG {
+		err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality})
+		return buffer.Bytes(), "image/jpeg", err
+	}
+	err := png.Encode(&buffer, img)
+	return buffer.Bytes(), "image/png", err
+}
+
+func minFloat(a float64, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+package library
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind of media guessed from file name
+const (
+	KindMovie   = "movie"
+	KindEpisode = "episode"
+)
+
+// Parsed is what a file name tells about the media
+type Parsed struct {
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Year    string `json:"year"`
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
+	Quality string `json:"quality"`
+}
+
+var (
+	episodeName = regexp.MustCompile(`(?i)^(.*?)[\s._-]*(?:s(\d{1,2})[\s._-]*e(\d{1,3})|(\d{1,2})x(\d{2,3}))\b`)
+	movieName   = regexp.MustCompile(`^(.*)[\s._]*[(\[]?((?:19|20)\d{2})[)\]]?(?:[\s._]|$)`)
+	qualityName = regexp.MustCompile(`(?i)\b(2160|1080|720|480)p\b`)
+	separators  = regexp.MustCompile(`[\s._]+`)
+)
+
+// Parse guess the media of a slash separated path, ex: "Title (2019).mkv" or "Show S01E02.mkv".
+// HLS playlist is named after its folder, ex: "Title (2019)/index.m3u8"
+func Parse(name string) Parsed {
+	base := path.Base(name)
+	if strings.EqualFold(path.Ext(base), ".m3u8") {
+		base = path.Base(path.Dir(name))
+	}
+	base = strings.TrimSuffix(base, path.Ext(base))
+
+	parsed := Parsed{Kind: KindMovie}
+	if match := qualityName.FindStringSubmatch(base); match != nil {
+		parsed.Quality = match[1] + "p"
+	}
+
+	if match := episodeName.FindStringSubmatch(base); match != nil {
+		season, episode := match[2], match[3]
+		if len(season) == 0 {
+			season, episode = match[4], match[5]
+		}
+		parsed.Kind = KindEpisode
+		parsed.Title = cleanTitle(match[1])
+		parsed.Season, _ = strconv.Atoi(season)
+		parsed.Episode, _ = strconv.Atoi(episode)
+
+		// Episode file is often named without the show, take it from the folder, ex: "Show/Season 1/S01E02.mkv"
+		if len(parsed.Title) == 0 {
+			parsed.Title = showFolder(name)
+		}
+		return parsed
+	}
+
+	if match := movieName.FindStringSubmatch(base); match != nil && len(cleanTitle(match[1])) != 0 {
+		parsed.Title = cleanTitle(match[1])
+		parsed.Year = match[2]
+		return parsed
+	}
+
+	// Drop release tags after the quality, ex: "Title 1080p BluRay"
+	if loc := qualityName.FindStringIndex(base); loc != nil {
+		base = base[:loc[0]]
+	}
+	parsed.Title = cleanTitle(base)
+	return parsed
+}
+
+// cleanTitle turns dot and underscore to space and trim separator
+func cleanTitle(title string) string {
+	title = separators.ReplaceAllString(title, " ")
+	return strings.Trim(title, " -([")
+}
+
+// showFolder returns the nearest folder which is not a season folder
+func showFolder(name string) string {
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		folder := path.Base(dir)
+		if !strings.HasPrefix(strings.ToLower(folder), "season") {
+			return cleanTitle(folder)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,265 @@
+package library
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/media"
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/provider"
+	"github.com/jinzhu/gorm"
+)
+
+// PlayerSource is source of player created by the scanner
+const PlayerSource = "library"
+
+// Extension of file the scanner picks up. HLS is only picked by its master playlist,
+// segments and fMP4 init segments next to a playlist are skipped
+var mediaExtensions = map[string]bool{
+	".mp4":  true,
+	".m4v":  true,
+	".mkv":  true,
+	".webm": true,
+	".avi":  true,
+}
+
+var playlistNames = map[string]bool{
+	"index.m3u8":  true,
+	"master.m3u8": true,
+}
+
+// Report is the summary of a scan
+type Report struct {
+	Scanned   int                    `json:"scanned"`
+	Created   int                    `json:"created"`
+	Existing  int                    `json:"existing"`
+	Unmatched []model.UnmatchedMedia `json:"unmatched"`
+}
+
+// Scanner walks media directories and creates local player for file matching the catalog
+type Scanner struct {
+	db   *gorm.DB
+	root string
+	dirs []string
+	tmdb provider.Provider
+}
+
+// NewScanner create scanner of dirs under root, TMDB search is used when title is not found in the catalog.
+// Nil tmdb disables the search
+func NewScanner(db *gorm.DB, root string, dirs []string, tmdb provider.Provider) *Scanner {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	return &Scanner{db: db, root: root, dirs: dirs, tmdb: tmdb}
+}
+
+// NewScannerFromEnv create scanner of MEDIA_SCAN_DIRS (comma separated, relative to MEDIA_ROOT).
+// MEDIA_SCAN_TMDB=true enables TMDB search with the tmdb provider of providers
+func NewScannerFromEnv(db *gorm.DB, providers provider.Providers) *Scanner {
+	dirs := []string{}
+	for _, dir := range strings.Split(os.Getenv("MEDIA_SCAN_DIRS"), ",") {
+		if dir = strings.TrimSpace(dir); len(dir) != 0 {
+			dirs = append(dirs, dir)
+		}
+	}
+	var tmdb provider.Provider
+	if os.Getenv("MEDIA_SCAN_TMDB") == "true" {
+		tmdb = providers["tmdb"]
+	}
+	return NewScanner(db, media.Root(), dirs, tmdb)
+}
+
+// Run scans periodically
+func (s *Scanner) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		report, err := s.Scan()
+		if err != nil {
+			log.Println("library: scan", err)
+			continue
+		}
+		log.Printf("library: scanned %d, created %d, unmatched %d", report.Scanned, report.Created, len(report.Unmatched))
+	}
+}
+
+// Scan walks every directory, directory which doesn't exist is skipped
+func (s *Scanner) Scan() (Report, error) {
+	report := Report{Unmatched: []model.UnmatchedMedia{}}
+
+	for _, dir := range s.dirs {
+		start, err := media.Resolve(s.root, dir)
+		if err == media.ErrOutsideRoot {
+			start = s.root
+		}
+
+		hls := map[string]bool{}
+		err = filepath.Walk(start, func(filename string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				// Variant playlists of a HLS output belong to its master playlist
+				if hls[filepath.Dir(filename)] {
+					return filepath.SkipDir
+				}
+				hls[filename] = hasPlaylist(filename)
+				return nil
+			}
+			if !isMedia(info.Name()) {
+				return nil
+			}
+			if hls[filepath.Dir(filename)] && !playlistNames[strings.ToLower(info.Name())] {
+				return nil
+			}
+
+			rel, err := filepath.Rel(s.root, filename)
+			if err != nil {
+				return err
+			}
+			return s.scanFile(filepath.ToSlash(rel), &report)
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// scanFile creates player of a file, or records it as unmatched
+func (s *Scanner) scanFile(name string, report *Report) error {
+	report.Scanned++
+
+	var count int
+	s.db.Unscoped().Model(&model.Player{}).Where("type = ? AND player_url = ?", model.PlayerLocal, name).Count(&count)
+	if count > 0 {
+		report.Existing++
+		return nil
+	}
+
+	parsed := Parse(name)
+	showID, showType, err := s.match(parsed)
+	if err != nil {
+		unmatched := model.UnmatchedMedia{}
+		if err := s.db.Where(model.UnmatchedMedia{Path: name}).
+			Assign(model.UnmatchedMedia{
+				Kind:    parsed.Kind,
+				Title:   parsed.Title,
+				Year:    parsed.Year,
+				Season:  parsed.Season,
+				Episode: parsed.Episode,
+				Quality: parsed.Quality,
+				Reason:  err.Error(),
+			}).
+			FirstOrCreate(&unmatched).Error; err != nil {
+			return err
+		}
+		report.Unmatched = append(report.Unmatched, unmatched)
+		return nil
+	}
+
+	if _, err := AddPlayer(s.db, name, parsed.Quality, showID, showType); err != nil {
+		return err
+	}
+	report.Created++
+	return nil
+}
+
+// match finds the movie or the episode of the parsed file
+func (s *Scanner) match(parsed Parsed) (uint, string, error) {
+	if len(parsed.Title) == 0 {
+		return 0, "", errors.New("title not recognized")
+	}
+
+	if parsed.Kind == KindMovie {
+		movie := model.Movie{}
+		query := s.db.Where("LOWER(title) = LOWER(?)", parsed.Title)
+		if len(parsed.Year) != 0 {
+			query = query.Where("release_date LIKE ?", parsed.Year+"%")
+		}
+		if err := query.First(&movie).Error; err == nil {
+			return movie.ID, "movies", nil
+		}
+		if tmdbID := s.searchTMDB("movie", parsed); tmdbID != 0 && s.db.Where("tmdb_id = ?", tmdbID).First(&movie).Error == nil {
+			return movie.ID, "movies", nil
+		}
+		return 0, "", errors.New("movie not found")
+	}
+
+	tv := model.Tv{}
+	if err := s.db.Where("LOWER(name) = LOWER(?)", parsed.Title).First(&tv).Error; err != nil {
+		tmdbID := s.searchTMDB("tv", parsed)
+		if tmdbID == 0 || s.db.Where("tmdb_id = ?", tmdbID).First(&tv).Error != nil {
+			return 0, "", errors.New("tv not found")
+		}
+	}
+
+	season := model.TvSeason{}
+	if err := s.db.Where("tv_id = ? AND season_number = ?", tv.ID, parsed.Season).First(&season).Error; err != nil {
+		return 0, "", errors.New("season " + strconv.Itoa(parsed.Season) + " not found")
+	}
+
+	episode := model.TvEpisode{}
+	if err := s.db.Where("tv_season_id = ? AND episode_number = ?", season.ID, parsed.Episode).First(&episode).Error; err != nil {
+		return 0, "", errors.New("episode " + strconv.Itoa(parsed.Episode) + " not found")
+	}
+	return episode.ID, "tv_episodes", nil
+}
+
+// searchTMDB returns TMDB id of the best result, zero when disabled or not found
+func (s *Scanner) searchTMDB(typeName string, parsed Parsed) int {
+	if s.tmdb == nil {
+		return 0
+	}
+
+	results, err := s.tmdb.Search(typeName, parsed.Title, parsed.Year)
+	if err != nil || len(results) == 0 {
+		return 0
+	}
+	return results[0].ID
+}
+
+// AddPlayer creates local player of a library file as the last fallback of the show,
+// and removes it from the unmatched list
+func AddPlayer(db *gorm.DB, name string, quality string, showID uint, showType string) (model.Player, error) {
+	var count int
+	db.Model(&model.Player{}).Where("show_id = ? AND show_type = ?", showID, showType).Count(&count)
+
+	player := model.Player{
+		Type:      model.PlayerLocal,
+		Source:    PlayerSource,
+		PlayerURL: name,
+		ShowID:    int(showID),
+		ShowType:  showType,
+		Quality:   quality,
+		Priority:  count,
+	}
+	if err := db.Create(&player).Error; err != nil {
+		return player, err
+	}
+
+	err := db.Unscoped().Where("path = ?", name).Delete(&model.UnmatchedMedia{}).Error
+	return player, err
+}
+
+func isMedia(name string) bool {
+	if playlistNames[strings.ToLower(name)] {
+		return true
+	}
+	return mediaExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// hasPlaylist reports whether dir is a HLS output, its other files are segments of the playlist
+func hasPlaylist(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.m3u8"))
+	return len(matches) != 0
+}
@@ -0,0 +1,171 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Content type of streamable media by file extension
+var contentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mkv":  "video/x-matroska",
+	".webm": "video/webm",
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+	".m4s":  "video/iso.segment",
+	".aac":  "audio/aac",
+	".vtt":  "text/vtt",
+}
+
+// Extensions of file served next to a playlist, .mp4 is the init segment of fragmented MP4
+var segmentExtensions = map[string]bool{
+	".ts": true, ".m4s": true, ".aac": true, ".vtt": true, ".key": true, ".m3u8": true, ".mp4": true,
+}
+
+// maxPlaylistDepth limits nested playlists followed by PlaylistFiles, master and its variants is 2
+const maxPlaylistDepth = 3
+
+var (
+	// ErrOutsideRoot is returned when a path escapes the media root, ex: ../../etc/passwd
+	ErrOutsideRoot = errors.New("path is outside media root")
+
+	playlistURI = regexp.MustCompile(`URI="([^"]*)"`)
+)
+
+// Root returns directory of local media, MEDIA_ROOT or media/ by default
+func Root() string {
+	root := os.Getenv("MEDIA_ROOT")
+	if len(root) == 0 {
+		root = "media"
+	}
+	return root
+}
+
+// Resolve join slash separated name to the root, name can not leave the root
+func Resolve(root string, name string) (string, error) {
+	cleaned := path.Clean("/" + name)
+	if cleaned == "/" {
+		return "", ErrOutsideRoot
+	}
+	return filepath.Join(root, filepath.FromSlash(cleaned)), nil
+}
+
+// ContentType returns content type of a media file by its extension
+func ContentType(name string) string {
+	return contentTypes[strings.ToLower(filepath.Ext(name))]
+}
+
+// IsPlaylist reports whether the file is HLS playlist
+func IsPlaylist(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".m3u8"
+}
+
+// IsSegment reports whether the file can be part of HLS stream, ex: segment, key or variant playlist
+func IsSegment(name string) bool {
+	return segmentExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// PlaylistFiles returns every file referenced by the playlist under root and by its nested playlists,
+// named relative to the directory of the playlist. Absolute URI is skipped since it isn't served from root
+func PlaylistFiles(root string, playlist string) (map[string]bool, error) {
+	files := map[string]bool{}
+	dir := path.Dir(path.Clean("/" + playlist))
+	err := collectPlaylistFiles(root, dir, path.Clean("/"+playlist), files, 1)
+	return files, err
+}
+
+func collectPlaylistFiles(root string, dir string, playlist string, files map[string]bool, depth int) error {
+	filename, err := Resolve(root, playlist)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	for _, uri := range playlistURIs(data) {
+		if strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+			continue
+		}
+		if i := strings.IndexAny(uri, "?#"); i >= 0 {
+			uri = uri[:i]
+		}
+		name := path.Join(path.Dir(playlist), uri)
+		relative := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+		if files[relative] || !strings.HasPrefix(name, strings.TrimSuffix(dir, "/")+"/") {
+			continue
+		}
+		files[relative] = true
+
+		// Variant playlist failing to read only loses its own segments
+		if IsPlaylist(name) && depth < maxPlaylistDepth {
+			collectPlaylistFiles(root, dir, name, files, depth+1)
+		}
+	}
+	return nil
+}
+
+// playlistURIs returns URI lines and URI attributes of tags, ex: #EXT-X-KEY:URI="key.bin"
+func playlistURIs(data []byte) []string {
+	uris := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		switch {
+		case len(trimmed) == 0:
+		case strings.HasPrefix(trimmed, "#"):
+			for _, match := range playlistURI.FindAllStringSubmatch(trimmed, -1) {
+				uris = append(uris, match[1])
+			}
+		default:
+			uris = append(uris, trimmed)
+		}
+	}
+	return uris
+}
+
+// RewritePlaylist append query to every relative URI of HLS playlist,
+// so segments and variant playlists keep the access signature
+func RewritePlaylist(data []byte, query string) []byte {
+	var buffer bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case len(trimmed) == 0:
+		case strings.HasPrefix(trimmed, "#"):
+			line = playlistURI.ReplaceAllStringFunc(line, func(match string) string {
+				uri := playlistURI.FindStringSubmatch(match)[1]
+				return `URI="` + withQuery(uri, query) + `"`
+			})
+		default:
+			line = withQuery(trimmed, query)
+		}
+
+		buffer.WriteString(line)
+		buffer.WriteString("\n")
+	}
+	return buffer.Bytes()
+}
+
+// withQuery adds query to relative URI, absolute URI is left as is
+func withQuery(uri string, query string) string {
+	if strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") || len(query) == 0 {
+		return uri
+	}
+	if strings.Contains(uri, "?") {
+		return uri + "&" + query
+	}
+	return uri + "?" + query
+}
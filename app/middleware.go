@@ -2,10 +2,14 @@ package app
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/condrowiyono/ruangtengah-api/app/model"
+	"github.com/condrowiyono/ruangtengah-api/app/storage"
 	"github.com/dgrijalva/jwt-go"
 )
 
@@ -32,11 +36,64 @@ func authMiddleware(next http.Handler) http.Handler {
 		username := claims.(jwt.MapClaims)["username"].(string)
 
 		r.Header.Set("username", username)
+		setProfileHeader(r, claims)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// optionalAuthMiddleware sets the username header when a valid token is sent,
+// but let anonymous request pass through
+func optionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del("username")
+		r.Header.Del("profile")
+
+		tokenString := r.Header.Get("Authorization")
+		if len(tokenString) != 0 {
+			tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+			claims, err := verifyToken(tokenString)
+			if err == nil {
+				if username, ok := claims.(jwt.MapClaims)["username"].(string); ok {
+					r.Header.Set("username", username)
+					setProfileHeader(r, claims)
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminMiddleware only let logged in user with admin role through
+func (a *App) adminMiddleware(next http.Handler) http.Handler {
+	return authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !model.IsAdmin(a.DB, r.Header.Get("username")) {
+			message := map[string]interface{}{"error": "Admin role required"}
+			messageJSON, _ := json.Marshal(message)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(messageJSON)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// setProfileHeader pass the active profile from X-Profile-ID header or the token claim.
+// Handler must check the profile belongs to the user
+func setProfileHeader(r *http.Request, claims jwt.Claims) {
+	profile := r.Header.Get("X-Profile-ID")
+	if profileID, ok := claims.(jwt.MapClaims)["profile_id"].(float64); ok && len(profile) == 0 {
+		profile = strconv.Itoa(int(profileID))
+	}
+
+	r.Header.Del("profile")
+	if len(profile) != 0 {
+		r.Header.Set("profile", profile)
+	}
+}
+
 func verifyToken(tokenString string) (jwt.Claims, error) {
 	signingKey := []byte(os.Getenv("JWT_SECRET"))
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -73,6 +130,36 @@ func (a *App) GetWithAuth(path string, f func(w http.ResponseWriter, r *http.Req
 	a.Router.Handle(path, authMiddleware(http.HandlerFunc(f))).Methods("GET")
 }
 
+// GetWithOptionalAuth : Wrap the router for GET method, user is optional
+func (a *App) GetWithOptionalAuth(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.Handle(path, optionalAuthMiddleware(http.HandlerFunc(f))).Methods("GET")
+}
+
+// PostWithOptionalAuth : Wrap the router for POST method, user is optional
+func (a *App) PostWithOptionalAuth(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.Handle(path, optionalAuthMiddleware(http.HandlerFunc(f))).Methods("POST")
+}
+
+// GetWithAdmin : Wrap the router for GET method, only for admin
+func (a *App) GetWithAdmin(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.Handle(path, a.adminMiddleware(http.HandlerFunc(f))).Methods("GET")
+}
+
+// PostWithAdmin : Wrap the router for POST method, only for admin
+func (a *App) PostWithAdmin(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.Handle(path, a.adminMiddleware(http.HandlerFunc(f))).Methods("POST")
+}
+
+// PutWithAdmin : Wrap the router for PUT method, only for admin
+func (a *App) PutWithAdmin(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.Handle(path, a.adminMiddleware(http.HandlerFunc(f))).Methods("PUT")
+}
+
+// DeleteWithAdmin : Wrap the router for DELETE method, only for admin
+func (a *App) DeleteWithAdmin(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.Handle(path, a.adminMiddleware(http.HandlerFunc(f))).Methods("DELETE")
+}
+
 // PostWithAuth : Wrap the router for POST method
 func (a *App) PostWithAuth(path string, f func(w http.ResponseWriter, r *http.Request)) {
 	a.Router.Handle(path, authMiddleware(http.HandlerFunc(f))).Methods("POST")
@@ -88,8 +175,14 @@ func (a *App) DeleteWithAuth(path string, f func(w http.ResponseWriter, r *http.
 	a.Router.Handle(path, authMiddleware(http.HandlerFunc(f))).Methods("DELETE")
 }
 
-// Static handle router for static file
+// Static handle router for static file, uploads are only served by the app on local storage
 func (a *App) Static() {
+	mime.AddExtensionType(".vtt", "text/vtt")
+
+	local, ok := a.Storage.(*storage.Local)
+	if !ok {
+		return
+	}
 	a.Router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/",
-		http.FileServer(http.Dir("uploads/"))))
+		http.FileServer(http.Dir(local.Dir))))
 }
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// UnratedAge is minimum age assumed for show without certification
+const UnratedAge = 18
+
+// certificationAges map known certification of a country to its minimum age
+var certificationAges = map[string]map[string]int{
+	// Lembaga Sensor Film
+	"ID": {"SU": 0, "P": 0, "A": 0, "BO": 0, "R": 13, "13+": 13, "D": 17, "17+": 17, "21+": 21},
+	// MPAA and TV Parental Guidelines
+	"US": {
+		"G": 0, "PG": 10, "PG-13": 13, "R": 17, "NC-17": 18,
+		"TV-Y": 0, "TV-Y7": 7, "TV-G": 0, "TV-PG": 10, "TV-14": 14, "TV-MA": 17,
+	},
+	// BBFC
+	"GB": {"U": 0, "PG": 8, "12A": 12, "12": 12, "15": 15, "18": 18, "R18": 18},
+}
+
+var certificationDigit = regexp.MustCompile(`\d+`)
+
+// Certification is age rating of a show in a country, ex: LSF 13+ or MPAA PG-13
+type Certification struct {
+	gorm.Model
+	Country  string `json:"country"`
+	Rating   string `json:"rating"`
+	MinAge   int    `json:"min_age"`
+	ShowID   int    `json:"show_id"`
+	ShowType string `json:"show_type"`
+}
+
+// BeforeSave fill minimum age from the rating
+func (c *Certification) BeforeSave() error {
+	c.Country = strings.ToUpper(c.Country)
+	c.MinAge = CertificationAge(c.Country, c.Rating)
+	return nil
+}
+
+// CertificationAge returns minimum age of a certification.
+// Unknown rating fallback to the number inside it, ex: FSK 16, otherwise UnratedAge
+func CertificationAge(country string, rating string) int {
+	rating = strings.ToUpper(strings.TrimSpace(rating))
+
+	if age, ok := certificationAges[strings.ToUpper(country)][rating]; ok {
+		return age
+	}
+	if digit := certificationDigit.FindString(rating); len(digit) != 0 {
+		age, _ := strconv.Atoi(digit)
+		return age
+	}
+	return UnratedAge
+}
+
+// IsKnownRating tells whether rating is a certification of the country, either a known one
+// or given to a show already
+func IsKnownRating(db *gorm.DB, country string, rating string) bool {
+	country = strings.ToUpper(country)
+	rating = strings.ToUpper(strings.TrimSpace(rating))
+	if _, ok := certificationAges[country][rating]; ok {
+		return true
+	}
+
+	var count int
+	db.Model(&Certification{}).Where("country = ? AND UPPER(rating) = ?", country, rating).Count(&count)
+	return count > 0
+}
@@ -16,15 +16,18 @@ type Artist struct {
 // Concert s
 type Concert struct {
 	gorm.Model
-	Title       string  `json:"title"`
-	ArtistID    int     `json:"-"`
-	ConcertDate string  `json:"concert_date"`
-	ReleaseDate string  `json:"release_date"`
-	Place       string  `json:"place"`
-	Overview    string  `json:"overview" gorm:"type:text"`
-	Setlist     string  `json:"setlist" gorm:"type:text"`
-	Artist      Artist  `json:"artist"`
-	Player      Player  `json:"player" gorm:"polymorphic:Show;"`
-	Videos      []Video `json:"videos" gorm:"polymorphic:Show;"`
-	Banners     []Image `json:"banners" gorm:"many2many:concerts_banners;"`
+	Title       string   `json:"title"`
+	ArtistID    int      `json:"-"`
+	ConcertDate string   `json:"concert_date"`
+	ReleaseDate string   `json:"release_date"`
+	Place       string   `json:"place"`
+	Overview    string   `json:"overview" gorm:"type:text"`
+	Setlist     string   `json:"setlist" gorm:"type:text"`
+	Artist      Artist   `json:"artist"`
+	Players     []Player `json:"players" gorm:"polymorphic:Show;"`
+	Videos      []Video  `json:"videos" gorm:"polymorphic:Show;"`
+	Banners     []Image  `json:"banners" gorm:"many2many:concerts_banners;"`
+
+	Images         []ImageAttachment `json:"images" gorm:"polymorphic:Owner;"`
+	Certifications []Certification   `json:"certifications" gorm:"polymorphic:Show;"`
 }
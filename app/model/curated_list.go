@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// CuratedList is an editorial list, ex: Best Indonesian films of 2019.
+// It can mix movies, tv shows and concerts
+type CuratedList struct {
+	gorm.Model
+	Title       string            `json:"title"`
+	Description string            `json:"description" gorm:"type:text"`
+	CoverID     int               `json:"cover_id"`
+	Cover       Image             `json:"cover"`
+	Published   bool              `json:"published"`
+	Items       []CuratedListItem `json:"items"`
+}
+
+// CuratedListItem is an ordered show inside a curated list
+type CuratedListItem struct {
+	gorm.Model
+	CuratedListID int         `json:"curated_list_id"`
+	ShowID        int         `json:"show_id"`
+	ShowType      string      `json:"show_type"`
+	Position      int         `json:"position"`
+	Show          interface{} `json:"show" gorm:"-"`
+}
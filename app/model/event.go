@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// Playback event types sent by the players
+const (
+	EventPlay     = "play"
+	EventPause    = "pause"
+	EventSeek     = "seek"
+	EventComplete = "complete"
+	EventError    = "error"
+)
+
+// PlaybackEvent is a single player interaction reported by the client.
+// ShowID and ShowType are resolved from the Player or Video when stored
+type PlaybackEvent struct {
+	gorm.Model
+	Type       string    `json:"type"`
+	PlayerID   int       `json:"player_id"`
+	VideoID    int       `json:"video_id"`
+	ShowID     int       `json:"show_id"`
+	ShowType   string    `json:"show_type"`
+	SessionID  string    `json:"session_id"`
+	Viewer     string    `json:"-"`
+	Username   string    `json:"-" gorm:"index"`
+	ProfileID  int       `json:"-" gorm:"index"`
+	Position   int       `json:"position"`
+	Duration   int       `json:"duration"`
+	Message    string    `json:"message" gorm:"type:text"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"index"`
+}
+
+// TitleStat is daily playback rollup of a show
+type TitleStat struct {
+	gorm.Model
+	Date           string  `json:"date" gorm:"index"`
+	ShowID         int     `json:"show_id"`
+	ShowType       string  `json:"show_type"`
+	Views          int     `json:"views"`
+	UniqueViewers  int     `json:"unique_viewers"`
+	Completions    int     `json:"completions"`
+	Errors         int     `json:"errors"`
+	CompletionRate float64 `json:"completion_rate"`
+}
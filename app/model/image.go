@@ -1,6 +1,11 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
 )
@@ -9,8 +14,56 @@ import (
 // Including Type banner, poster, profile_picture, general, etc
 type Image struct {
 	gorm.Model
-	Type    string `json:"type"`
-	Keyword string `json:"keyword"`
-	Source  string `json:"source"`
-	Path    string `json:"path" gorm:"type:text"`
+	Type       string     `json:"type"`
+	Keyword    string     `json:"keyword"`
+	Source     string     `json:"source"`
+	Path       string     `json:"path" gorm:"type:text"`
+	Width      int        `json:"width"`
+	Height     int        `json:"height"`
+	MimeType   string     `json:"mime_type"`
+	Renditions Renditions `json:"renditions" gorm:"type:text"`
+	Keys       Renditions `json:"-" gorm:"column:storage_keys;type:text"` // storage key of every rendition
+	OriginURL  string     `json:"origin_url" gorm:"type:text"`
+	SHA256     string     `json:"sha256" gorm:"column:sha256;type:varchar(64);index"` // of the uploaded file
+	PHash      uint64     `json:"phash,string" gorm:"column:phash"`                   // perceptual hash
+
+	// Placeholder shown while the image loads
+	BlurHash      string `json:"blur_hash"`
+	DominantColor string `json:"dominant_color" gorm:"type:varchar(7)"`
+	AverageColor  string `json:"average_color" gorm:"type:varchar(7)"`
+
+	MirrorAttempts int    `json:"-" gorm:"default:0"`
+	MirrorError    string `json:"-" gorm:"type:text"`
+
+	BackfillAttempts int    `json:"-" gorm:"default:0"`
+	BackfillError    string `json:"-" gorm:"type:text"`
+
+	// First time garbage collection found the image unused, cleared when it is used again
+	UnusedSince *time.Time `json:"unused_since"`
+}
+
+// Renditions is URL of every resized copy of an image by its name, ex: thumbnail, w500, original
+type Renditions map[string]string
+
+// Value stores renditions as JSON
+func (r Renditions) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(r)
+	return string(data), err
+}
+
+// Scan reads renditions from JSON
+func (r *Renditions) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, r)
+	case string:
+		return json.Unmarshal([]byte(data), r)
+	}
+	return errors.New("renditions must be JSON text")
 }
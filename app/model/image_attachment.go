@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// Role of an attached image
+const (
+	ImagePoster  = "poster"
+	ImageBanner  = "banner"
+	ImageLogo    = "logo"
+	ImageStill   = "still"
+	ImageProfile = "profile"
+)
+
+// ImageRoles are every valid role
+var ImageRoles = []string{ImagePoster, ImageBanner, ImageLogo, ImageStill, ImageProfile}
+
+// ImageAttachment puts an image on a movie, tv, season, episode, concert or person.
+// Images of the same role are ordered by position, one of them is primary
+type ImageAttachment struct {
+	gorm.Model
+	ImageID   uint   `json:"image_id" gorm:"index"`
+	Image     Image  `json:"image"`
+	OwnerID   int    `json:"owner_id" gorm:"index:idx_image_attachment_owner"`
+	OwnerType string `json:"owner_type" gorm:"index:idx_image_attachment_owner"`
+	Role      string `json:"role"`
+	Language  string `json:"language"` // ISO 639-1, empty for image without text
+	Position  int    `json:"position"`
+	IsPrimary bool   `json:"primary"`
+}
+
+// IsImageRole tells whether role is valid
+func IsImageRole(role string) bool {
+	for _, valid := range ImageRoles {
+		if role == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// UnmatchedMedia is a library file the scanner could not match to the catalog, kept for manual review
+type UnmatchedMedia struct {
+	gorm.Model
+	Path    string `json:"path" gorm:"type:varchar(512);unique_index"`
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Year    string `json:"year"`
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
+	Quality string `json:"quality"`
+	Reason  string `json:"reason"`
+}
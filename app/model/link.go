@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// LinkHealth is the last probe result of a remote source URL
+type LinkHealth struct {
+	LinkStatus    int        `json:"link_status"`
+	LinkLatency   int        `json:"link_latency"` // millisecond
+	LinkError     string     `json:"link_error" gorm:"type:text"`
+	LinkCheckedAt *time.Time `json:"link_checked_at"`
+	LinkFailures  int        `json:"link_failures" gorm:"default:0"` // consecutive failed checks
+	Broken        bool       `json:"broken" gorm:"index;default:false"`
+}
@@ -24,6 +24,29 @@ func DBMigrate(db *gorm.DB) *gorm.DB {
 		&TvEpisode{},
 		&TvSeason{},
 		&Tv{},
+		&Rail{},
+		&RailItem{},
+		&CuratedList{},
+		&CuratedListItem{},
+		&PlaybackEvent{},
+		&TitleStat{},
+		&TrendingScore{},
+		&Certification{},
+		&Profile{},
+		&Subtitle{},
+		&UnmatchedMedia{},
+		&ImageAttachment{},
 	)
+
+	// Column added by AutoMigrate is NULL on existing rows, which never equals false
+	db.Model(&Player{}).Where("broken IS NULL").UpdateColumn("broken", false)
+	db.Model(&Video{}).Where("broken IS NULL").UpdateColumn("broken", false)
+	db.Model(&Player{}).Where("link_failures IS NULL").UpdateColumn("link_failures", 0)
+	db.Model(&Video{}).Where("link_failures IS NULL").UpdateColumn("link_failures", 0)
+	db.Model(&Image{}).Where("mirror_attempts IS NULL").UpdateColumn("mirror_attempts", 0)
+	db.Model(&Image{}).Where("backfill_attempts IS NULL").UpdateColumn("backfill_attempts", 0)
+
+	// AutoMigrate never changes the type of a column, bio was varchar(255) before
+	db.Model(&Person{}).ModifyColumn("bio", "text")
 	return db
 }
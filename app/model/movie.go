@@ -22,6 +22,9 @@ type Movie struct {
 	Banners     []Image      `json:"banners" gorm:"many2many:movies_banners;"`
 	Posters     []Image      `json:"posters" gorm:"many2many:movies_posters;"`
 	Genres      []Genre      `json:"genres" gorm:"many2many:movies_genres;association_autocreate:false;"`
-	Player      Player       `json:"player" gorm:"polymorphic:Show;"`
+	Players     []Player     `json:"players" gorm:"polymorphic:Show;"`
 	Videos      []Video      `json:"videos" gorm:"polymorphic:Show;"`
+
+	Images         []ImageAttachment `json:"images" gorm:"polymorphic:Owner;"`
+	Certifications []Certification   `json:"certifications" gorm:"polymorphic:Show;"`
 }
@@ -10,6 +10,14 @@ import (
 type Person struct {
 	gorm.Model
 	Name    string `json:"name"`
-	Bio     string `json:"bio"`
+	Bio     string `json:"bio" gorm:"type:text"`
 	Picture string `json:"picture"`
+
+	// Filled by import from TMDB, same TMDB id is always the same person
+	TmdbID             int    `json:"tmdb_id" gorm:"index"`
+	Birthday           string `json:"birthday"`
+	PlaceOfBirth       string `json:"place_of_birth"`
+	KnownForDepartment string `json:"known_for_department"`
+
+	Images []ImageAttachment `json:"images" gorm:"polymorphic:Owner;"`
 }
@@ -5,8 +5,16 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
 )
 
+// Player type, URL of local player is a slash separated path under MEDIA_ROOT.
+// Empty type is treated as external
+const (
+	PlayerExternal = "external"
+	PlayerLocal    = "local"
+)
+
 // Player will act player for every media supported in this app.
 // That include movie, tv series, anime, bal-balan, etc
+// A show can have many players, client fall back following the priority
 type Player struct {
 	gorm.Model
 	Type      string `json:"type" gorm:"type:text"`
@@ -14,4 +22,11 @@ type Player struct {
 	PlayerURL string `json:"player_url" gorm:"type:text"`
 	ShowID    int    `json:"show_id"`
 	ShowType  string `json:"show_type"`
+	Quality   string `json:"quality"`
+	Language  string `json:"language"`
+	Priority  int    `json:"priority"`
+	Enabled   *bool  `json:"enabled" gorm:"default:true"` // nil is saved as enabled
+	LinkHealth
+
+	Subtitles []Subtitle `json:"subtitles"`
 }
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// KidsMaxAge is age limit of kids profile
+const KidsMaxAge = 7
+
+// Profile is a viewer sharing a user account, ex: each member of a household.
+// Personal data like playback progress is scoped to the active profile
+type Profile struct {
+	gorm.Model
+	UserID    int    `json:"user_id"`
+	Name      string `json:"name"`
+	AvatarID  int    `json:"avatar_id"`
+	Avatar    Image  `json:"avatar"`
+	Kids      bool   `json:"kids"`
+	Language  string `json:"language"`
+	MaxRating string `json:"max_rating"`
+}
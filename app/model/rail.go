@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// Rail types supported on the home screen
+const (
+	RailNewlyAdded       = "newly_added"
+	RailTrending         = "trending"
+	RailGenre            = "genre"
+	RailContinueWatching = "continue_watching"
+	RailEditorial        = "editorial"
+)
+
+// Rail is a single row of the home screen.
+// Editors configure and reorder them without a deploy
+type Rail struct {
+	gorm.Model
+	Title         string      `json:"title"`
+	Type          string      `json:"type"`
+	ShowType      string      `json:"show_type"`
+	Genre         string      `json:"genre"`
+	CuratedListID int         `json:"curated_list_id"`
+	Size          int         `json:"size"`
+	Position      int         `json:"position"`
+	Enabled       bool        `json:"enabled"`
+	Items         []RailItem  `json:"items"`
+	Shows         interface{} `json:"shows" gorm:"-"`
+}
+
+// RailItem is a hand picked show inside an editorial rail.
+// Editorial rail with CuratedListID use the list items instead
+type RailItem struct {
+	gorm.Model
+	RailID   int    `json:"rail_id"`
+	ShowID   int    `json:"show_id"`
+	ShowType string `json:"show_type"`
+	Position int    `json:"position"`
+}
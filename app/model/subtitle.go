@@ -0,0 +1,19 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// Subtitle is a WebVTT track of a player.
+// Uploaded SRT and ASS are converted to WebVTT before stored
+type Subtitle struct {
+	gorm.Model
+	PlayerID int    `json:"player_id"`
+	Language string `json:"language"`
+	Label    string `json:"label"`
+	Default  bool   `json:"default" gorm:"column:is_default"`
+	Forced   bool   `json:"forced" gorm:"column:is_forced"`
+	Path     string `json:"-" gorm:"type:text"`
+	URL      string `json:"url" gorm:"type:text"`
+}
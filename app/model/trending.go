@@ -0,0 +1,23 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" //asas
+)
+
+// Trending periods
+const (
+	TrendingDay  = "day"
+	TrendingWeek = "week"
+)
+
+// TrendingScore is time decayed popularity of a show in a period
+type TrendingScore struct {
+	gorm.Model
+	Period   string      `json:"period" gorm:"index"`
+	ShowID   int         `json:"show_id"`
+	ShowType string      `json:"show_type"`
+	Score    float64     `json:"score"`
+	Plays    int         `json:"plays"`
+	Show     interface{} `json:"show" gorm:"-"`
+}
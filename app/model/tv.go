@@ -13,6 +13,7 @@ type TvCreator struct {
 
 type TvSeason struct {
 	gorm.Model
+	TvID         int         `json:"tv_id"`
 	ReleaseDate  string      `json:"release_date"`
 	EpisodeCount int         `json:"episode_count"`
 	Name         string      `json:"name"`
@@ -20,17 +21,22 @@ type TvSeason struct {
 	SeasonNumber int         `json:"season_number"`
 	Poster       string      `json:"poster"`
 	Episodes     []TvEpisode `json:"episodes"`
+
+	Images []ImageAttachment `json:"images" gorm:"polymorphic:Owner;"`
 }
 
 type TvEpisode struct {
 	gorm.Model
-	AirDate       string `json:"air_date"`
-	EpisodeNumber int    `json:"episode_number"`
-	SeasonNumber  int    `json:"season_number"`
-	Name          string `json:"name"`
-	Overview      string `json:"overview" gorm:"type:text"`
-	Still         string `json:"still_path"`
-	Player        Player `json:"player" gorm:"polymorphic:Show;"`
+	TvSeasonID    int      `json:"tv_season_id"`
+	AirDate       string   `json:"air_date"`
+	EpisodeNumber int      `json:"episode_number"`
+	SeasonNumber  int      `json:"season_number"`
+	Name          string   `json:"name"`
+	Overview      string   `json:"overview" gorm:"type:text"`
+	Still         string   `json:"still_path"`
+	Players       []Player `json:"players" gorm:"polymorphic:Show;"`
+
+	Images []ImageAttachment `json:"images" gorm:"polymorphic:Owner;"`
 }
 
 // Tv hold every component detail about a tv show and drakor
@@ -52,4 +58,7 @@ type Tv struct {
 	Network      []Network    `json:"networks" gorm:"many2many:tv_networks;association_autocreate:false;"`
 	Creators     []TvCreator  `json:"creators" gorm:"many2many:tv_actors;association_autocreate:false;"`
 	Productions  []Production `json:"productions" gorm:"many2many:tv_productions;association_autocreate:false;"`
+
+	Images         []ImageAttachment `json:"images" gorm:"polymorphic:Owner;"`
+	Certifications []Certification   `json:"certifications" gorm:"polymorphic:Show;"`
 }
@@ -1,9 +1,17 @@
 package model
 
 import (
+	"strings"
+
 	"github.com/jinzhu/gorm"
 )
 
+// Role of user, only admin can access admin endpoints
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
 // User will serve as struct model
 type User struct {
 	gorm.Model
@@ -12,4 +20,35 @@ type User struct {
 	Name     string `json:"name"`
 	Role     string `json:"role"`
 	Password string `json:"password"`
+
+	// Parental control, empty MaxRating means no restriction
+	MaxRating     string `json:"max_rating"`
+	RatingCountry string `json:"rating_country"`
+
+	Profiles []Profile `json:"profiles"`
+}
+
+// IsRole tells whether role is a known role of user
+func IsRole(role string) bool {
+	return role == RoleAdmin || role == RoleUser
+}
+
+// IsAdmin tells whether the user of username has admin role
+func IsAdmin(db *gorm.DB, username string) bool {
+	if len(username) == 0 {
+		return false
+	}
+	user := User{}
+	return db.Where("username = ? AND role = ?", username, RoleAdmin).First(&user).Error == nil
+}
+
+// SeedAdmins promotes existing users of the usernames to admin, the first admin can't be promoted by another one
+func SeedAdmins(db *gorm.DB, usernames []string) error {
+	for i := range usernames {
+		usernames[i] = strings.TrimSpace(usernames[i])
+	}
+	if len(usernames) == 0 {
+		return nil
+	}
+	return db.Model(&User{}).Where("username IN (?)", usernames).Update("role", RoleAdmin).Error
 }
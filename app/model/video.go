@@ -13,4 +13,5 @@ type Video struct {
 	VideoURL string `json:"player_url" gorm:"type:text"`
 	ShowID   int    `json:"show_id"`
 	ShowType string `json:"show_type"`
+	LinkHealth
 }
@@ -0,0 +1,163 @@
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Options of the client, zero value uses the defaults
+type Options struct {
+	Timeout  time.Duration // deadline of every attempt, 10s by default
+	Retries  int           // attempts after the first one, negative turns retry off
+	Backoff  time.Duration // wait before the first retry, doubled after each, 500ms by default
+	MaxDelay time.Duration // longest wait before a retry, longer Retry-After isn't retried, 10s by default
+	Limiter  *Limiter      // nil doesn't limit
+}
+
+// OptionsFromEnv returns options of OUTBOUND_TIMEOUT, 10s by default, and OUTBOUND_RETRIES, 2 by default
+func OptionsFromEnv(limiter *Limiter) Options {
+	options := Options{Retries: 2, Limiter: limiter}
+	if timeout, err := time.ParseDuration(os.Getenv("OUTBOUND_TIMEOUT")); err == nil && timeout > 0 {
+		options.Timeout = timeout
+	}
+	if retries, err := strconv.Atoi(os.Getenv("OUTBOUND_RETRIES")); err == nil {
+		options.Retries = retries
+	}
+	return options
+}
+
+// NewClient create client for the upstream APIs. Every attempt has its own deadline and waits for the limiter,
+// GET is retried with backoff on network error, 429 and 5xx
+func NewClient(options Options) *http.Client {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Retries < 0 {
+		options.Retries = 0
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = 500 * time.Millisecond
+	}
+	if options.MaxDelay <= 0 {
+		options.MaxDelay = 10 * time.Second
+	}
+	return &http.Client{Transport: &transport{next: http.DefaultTransport, options: options}}
+}
+
+// StatusError is an unexpected response status of the upstream
+type StatusError struct {
+	Host       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded %d %s", e.Host, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// CheckStatus returns StatusError unless the response is 2xx
+func CheckStatus(res *http.Response) error {
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &StatusError{Host: res.Request.URL.Host, StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+// IsTimeout tells whether err is the upstream not answering before the deadline
+func IsTimeout(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	timeout, ok := err.(interface{ Timeout() bool })
+	return ok && timeout.Timeout()
+}
+
+type transport struct {
+	next    http.RoundTripper
+	options Options
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.options.Limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), t.options.Timeout)
+		res, err := t.next.RoundTrip(req.WithContext(ctx))
+
+		delay, retry := t.retryDelay(req, res, err, attempt)
+		if !retry {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// The deadline still covers reading the body, it ends when the body is closed
+			res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+			return res, nil
+		}
+
+		if res != nil {
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+		cancel()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryDelay returns how long to wait before the next attempt, or false when it shouldn't be retried.
+// Only request without body is retried since the body can't be sent again
+func (t *transport) retryDelay(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.options.Retries || req.Body != nil || (req.Method != "GET" && req.Method != "HEAD") {
+		return 0, false
+	}
+	if req.Context().Err() != nil {
+		return 0, false
+	}
+	if err == nil && res.StatusCode != http.StatusTooManyRequests && res.StatusCode < 500 {
+		return 0, false
+	}
+
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			delay := time.Duration(seconds) * time.Second
+			return delay, delay <= t.options.MaxDelay
+		}
+	}
+
+	delay := t.options.Backoff << uint(attempt)
+	if delay > t.options.MaxDelay {
+		delay = t.options.MaxDelay
+	}
+	// Jitter so clients failing together don't retry together
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)), true
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
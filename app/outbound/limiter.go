@@ -0,0 +1,103 @@
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket, a request takes a token and tokens come back at a steady rate
+// up to the burst. TMDB counts requests the same way
+type Limiter struct {
+	rate   float64 // tokens per second
+	burst  float64
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter allows n requests per period, all n at once when the bucket is full
+func NewLimiter(n int, period time.Duration) *Limiter {
+	return &Limiter{
+		rate:   float64(n) / period.Seconds(),
+		burst:  float64(n),
+		tokens: float64(n),
+		last:   time.Now(),
+	}
+}
+
+// ParseLimit parses n requests per period, ex: 40/1s or 1000/24h. Empty or 0 means no limit, nil is returned
+func ParseLimit(value string) (*Limiter, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 || value == "0" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n < 0 || len(parts) != 2 {
+		return nil, fmt.Errorf("outbound: limit must be requests/period, ex: 40/1s, got %q", value)
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("outbound: limit must be requests/period, ex: 40/1s, got %q", value)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return NewLimiter(n, period), nil
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = map[string]*Limiter{}
+)
+
+// Shared returns the limiter of name, every client of the same upstream must share it
+// to stay under its limit. Limit is parsed only the first time, invalid one means no limit
+func Shared(name string, limit string) *Limiter {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if limiter, ok := shared[name]; ok {
+		return limiter
+	}
+	limiter, _ := ParseLimit(limit)
+	shared[name] = limiter
+	return limiter
+}
+
+// Wait blocks until a token is taken or ctx is done, nil limiter never blocks
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package playback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned when the URL was not signed by us or was modified
+	ErrInvalidSignature = errors.New("invalid playback signature")
+	// ErrExpired is returned when the URL is signed properly but too old
+	ErrExpired = errors.New("playback url expired")
+)
+
+// Signer creates and verifies short lived playback URL query
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner create signer whose URL is valid for ttl
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte(secret), ttl: ttl}
+}
+
+// Sign returns expires, user and signature query of a player.
+// Empty username makes the URL usable by anyone holding it
+func (s *Signer) Sign(playerID uint, username string) url.Values {
+	expires := time.Now().Add(s.ttl).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	if len(username) != 0 {
+		query.Set("user", username)
+	}
+	query.Set("signature", s.signature(playerID, expires, username))
+	return query
+}
+
+// Verify checks signature and expiry of the query, returns the user the URL is bound to
+func (s *Signer) Verify(playerID uint, query url.Values) (string, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	username := query.Get("user")
+	expected := s.signature(playerID, expires, username)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return "", ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return "", ErrExpired
+	}
+	return username, nil
+}
+
+func (s *Signer) signature(playerID uint, expires int64, username string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%d:%d:%s", playerID, expires, username)
+	return hex.EncodeToString(mac.Sum(nil))
+}
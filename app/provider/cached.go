@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/condrowiyono/ruangtengah-api/app/cache"
+)
+
+// Cached keeps results of the provider in the cache, errors are never kept.
+// Keys start with the provider name and endpoint, ex: tmdb:movie:27205
+type Cached struct {
+	provider Provider
+	cache    *cache.Cache
+	ttls     cache.TTLs
+	tracker  *cache.Tracker
+}
+
+// NewCached create cached provider, endpoints missing in ttls are not cached
+func NewCached(source Provider, c *cache.Cache, ttls cache.TTLs) *Cached {
+	return &Cached{provider: source, cache: c, ttls: ttls}
+}
+
+// WithCache wraps every provider with the cache
+func (p Providers) WithCache(c *cache.Cache, ttls cache.TTLs) Providers {
+	cached := Providers{}
+	for name, source := range p {
+		cached[name] = NewCached(source, c, ttls)
+	}
+	return cached
+}
+
+// Track returns the provider recording status of its calls to tracker, provider without cache is returned as is
+func Track(source Provider, tracker *cache.Tracker) Provider {
+	if cached, ok := source.(*Cached); ok {
+		tracked := *cached
+		tracked.tracker = tracker
+		return &tracked
+	}
+	return source
+}
+
+func (c *Cached) Name() string {
+	return c.provider.Name()
+}
+
+func (c *Cached) Search(kind string, query string, year string) ([]SearchResult, error) {
+	results := []SearchResult{}
+	err := c.load("search", []interface{}{kind, strings.ToLower(query), year}, &results, func() (interface{}, error) {
+		return c.provider.Search(kind, query, year)
+	})
+	return results, err
+}
+
+func (c *Cached) Movie(id string) (*Movie, error) {
+	movie := Movie{}
+	if err := c.load("movie", []interface{}{id}, &movie, func() (interface{}, error) {
+		return c.provider.Movie(id)
+	}); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+func (c *Cached) Tv(id string) (*Tv, error) {
+	tv := Tv{}
+	if err := c.load("tv", []interface{}{id}, &tv, func() (interface{}, error) {
+		return c.provider.Tv(id)
+	}); err != nil {
+		return nil, err
+	}
+	return &tv, nil
+}
+
+func (c *Cached) TvSeason(id string, season int) (*Season, error) {
+	result := Season{}
+	if err := c.load("season", []interface{}{id, season}, &result, func() (interface{}, error) {
+		return c.provider.TvSeason(id, season)
+	}); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Cached) TvEpisode(id string, season int, episode int) (*Episode, error) {
+	result := Episode{}
+	if err := c.load("episode", []interface{}{id, season, episode}, &result, func() (interface{}, error) {
+		return c.provider.TvEpisode(id, season, episode)
+	}); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Cached) Images(kind string, id string) (*Images, error) {
+	images := Images{}
+	if err := c.load("images", []interface{}{kind, id}, &images, func() (interface{}, error) {
+		return c.provider.Images(kind, id)
+	}); err != nil {
+		return nil, err
+	}
+	return &images, nil
+}
+
+func (c *Cached) SearchPerson(query string) ([]PersonResult, error) {
+	results := []PersonResult{}
+	err := c.load("search", []interface{}{"people", strings.ToLower(query)}, &results, func() (interface{}, error) {
+		return c.provider.SearchPerson(query)
+	})
+	return results, err
+}
+
+func (c *Cached) Person(id string) (*Person, error) {
+	person := Person{}
+	if err := c.load("person", []interface{}{id}, &person, func() (interface{}, error) {
+		return c.provider.Person(id)
+	}); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// load keys the call by provider, endpoint and its arguments, ex: tmdb:season:1399:2
+func (c *Cached) load(endpoint string, args []interface{}, v interface{}, fetch func() (interface{}, error)) error {
+	key := c.provider.Name() + ":" + endpoint
+	for _, arg := range args {
+		key += ":" + fmt.Sprint(arg)
+	}
+
+	status, err := c.cache.Load(key, endpoint, c.ttls, v, fetch)
+	c.tracker.Record(status)
+	return err
+}
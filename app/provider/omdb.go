@@ -0,0 +1,338 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/app/outbound"
+)
+
+// OMDbBaseURL is the default OMDb server
+const OMDbBaseURL = "https://www.omdbapi.com"
+
+// OMDb is the Open Movie Database, titles are identified by IMDb id.
+// It has no people and only a single poster per title
+type OMDb struct {
+	baseURL string
+	key     string
+	client  *http.Client
+}
+
+// NewOMDb create OMDb provider, requests are limited by OMDB_RATE_LIMIT, ex: 1000/24h
+func NewOMDb(baseURL string, key string) *OMDb {
+	limiter := outbound.Shared("omdb", os.Getenv("OMDB_RATE_LIMIT"))
+	return &OMDb{baseURL: baseURL, key: key, client: newClient(limiter)}
+}
+
+// OMDbFromEnv create OMDb provider of OMDB_KEY and OMDB_BASE_URL
+func OMDbFromEnv() *OMDb {
+	return NewOMDb(getenv("OMDB_BASE_URL", OMDbBaseURL), os.Getenv("OMDB_KEY"))
+}
+
+// omdbTitle is the detail of a movie, series or episode
+type omdbTitle struct {
+	Title        string `json:"Title"`
+	Year         string `json:"Year"`
+	Rated        string `json:"Rated"`
+	Released     string `json:"Released"`
+	Runtime      string `json:"Runtime"`
+	Genre        string `json:"Genre"`
+	Director     string `json:"Director"`
+	Writer       string `json:"Writer"`
+	Actors       string `json:"Actors"`
+	Plot         string `json:"Plot"`
+	Country      string `json:"Country"`
+	Poster       string `json:"Poster"`
+	ImdbID       string `json:"imdbID"`
+	ImdbRating   string `json:"imdbRating"`
+	ImdbVotes    string `json:"imdbVotes"`
+	Type         string `json:"Type"`
+	TotalSeasons string `json:"totalSeasons"`
+	Season       string `json:"Season"`
+	Episode      string `json:"Episode"`
+	Production   string `json:"Production"`
+}
+
+func (o *OMDb) Name() string {
+	return "omdb"
+}
+
+// Search kind tv is searched as series
+func (o *OMDb) Search(kind string, query string, year string) ([]SearchResult, error) {
+	params := url.Values{"s": {query}, "type": {omdbType(kind)}}
+	if len(year) != 0 {
+		params.Set("y", year)
+	}
+
+	search := struct {
+		Search []omdbTitle `json:"Search"`
+	}{}
+	if err := o.get(params, &search); err != nil {
+		if err == ErrNotFound {
+			return []SearchResult{}, nil
+		}
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	for _, title := range search.Search {
+		result := SearchResult{
+			ImdbID:      title.ImdbID,
+			PosterURL:   omdbValue(title.Poster),
+			ReleaseDate: omdbYear(title.Year),
+		}
+		if kind == "tv" {
+			result.Name = title.Title
+		} else {
+			result.Title = title.Title
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (o *OMDb) Movie(id string) (*Movie, error) {
+	title, err := o.title(url.Values{"i": {id}, "plot": {"full"}})
+	if err != nil {
+		return nil, err
+	}
+
+	movie := Movie{
+		ImdbID:         title.ImdbID,
+		Title:          title.Title,
+		Overview:       omdbValue(title.Plot),
+		ReleaseDate:    omdbDate(title.Released),
+		Runtime:        omdbMinutes(title.Runtime),
+		PosterURL:      omdbValue(title.Poster),
+		Genres:         []MovieGenres{},
+		Certifications: omdbCertifications(title.Rated),
+	}
+	for _, name := range omdbList(title.Genre) {
+		movie.Genres = append(movie.Genres, MovieGenres{Name: name})
+	}
+	for _, name := range omdbList(title.Country) {
+		movie.ProductionCountries = append(movie.ProductionCountries, MovieProductionCountries{Name: name})
+	}
+	if production := omdbValue(title.Production); len(production) != 0 {
+		movie.ProductionCompanies = append(movie.ProductionCompanies, MovieProductionCompanies{Name: production})
+	}
+	for i, name := range omdbList(title.Actors) {
+		movie.Credits.Cast = append(movie.Credits.Cast, MovieCast{Name: name, Order: i})
+	}
+	for _, name := range omdbList(title.Director) {
+		movie.Credits.Crew = append(movie.Credits.Crew, MovieCrew{Name: name, Job: "Director", Department: "Directing"})
+	}
+	for _, name := range omdbList(title.Writer) {
+		movie.Credits.Crew = append(movie.Credits.Crew, MovieCrew{Name: name, Job: "Writer", Department: "Writing"})
+	}
+	return &movie, nil
+}
+
+// Tv seasons only have their number and name, use TvSeason for the episodes
+func (o *OMDb) Tv(id string) (*Tv, error) {
+	title, err := o.title(url.Values{"i": {id}, "plot": {"full"}})
+	if err != nil {
+		return nil, err
+	}
+
+	tv := Tv{
+		ImdbID:          title.ImdbID,
+		Name:            title.Title,
+		Overview:        omdbValue(title.Plot),
+		FirstAirDate:    omdbDate(title.Released),
+		NumberOfSeasons: omdbNumber(title.TotalSeasons),
+		PosterURL:       omdbValue(title.Poster),
+		Genres:          []Genres{},
+		Casts:           omdbList(title.Actors),
+		Certifications:  omdbCertifications(title.Rated),
+	}
+	if runtime := omdbMinutes(title.Runtime); runtime != 0 {
+		tv.EpisodeRunTime = []int{runtime}
+	}
+	for i, name := range tv.Casts {
+		tv.Credits.Cast = append(tv.Credits.Cast, MovieCast{Name: name, Order: i})
+	}
+	for _, name := range omdbList(title.Genre) {
+		tv.Genres = append(tv.Genres, Genres{Name: name})
+	}
+	for _, name := range omdbList(title.Writer) {
+		tv.CreatedBy = append(tv.CreatedBy, CreatedBy{Name: name})
+	}
+	for season := 1; season <= tv.NumberOfSeasons; season++ {
+		tv.Seasons = append(tv.Seasons, Season{SeasonNumber: season, Name: fmt.Sprintf("Season %d", season)})
+	}
+	return &tv, nil
+}
+
+func (o *OMDb) TvSeason(id string, season int) (*Season, error) {
+	response := struct {
+		Episodes []omdbTitle `json:"Episodes"`
+	}{}
+	if err := o.get(url.Values{"i": {id}, "Season": {strconv.Itoa(season)}}, &response); err != nil {
+		return nil, err
+	}
+
+	result := Season{SeasonNumber: season, Name: fmt.Sprintf("Season %d", season), Episode: []Episode{}}
+	for _, title := range response.Episodes {
+		result.Episode = append(result.Episode, Episode{
+			Name:          title.Title,
+			AirDate:       omdbDate(title.Released),
+			EpisodeNumber: omdbNumber(title.Episode),
+			SeasonNumber:  season,
+		})
+	}
+	result.EpisodeCount = len(result.Episode)
+	if result.EpisodeCount > 0 {
+		result.AirDate = result.Episode[0].AirDate
+	}
+	return &result, nil
+}
+
+func (o *OMDb) TvEpisode(id string, season int, episode int) (*Episode, error) {
+	title, err := o.title(url.Values{"i": {id}, "Season": {strconv.Itoa(season)}, "Episode": {strconv.Itoa(episode)}})
+	if err != nil {
+		return nil, err
+	}
+
+	rating, _ := strconv.ParseFloat(title.ImdbRating, 64)
+	votes, _ := strconv.Atoi(strings.Replace(title.ImdbVotes, ",", "", -1))
+	return &Episode{
+		Name:          title.Title,
+		Overview:      omdbValue(title.Plot),
+		AirDate:       omdbDate(title.Released),
+		EpisodeNumber: episode,
+		SeasonNumber:  season,
+		StillURL:      omdbValue(title.Poster),
+		VoteAverage:   rating,
+		VoteCount:     votes,
+	}, nil
+}
+
+// Images is the only poster of the title
+func (o *OMDb) Images(kind string, id string) (*Images, error) {
+	title, err := o.title(url.Values{"i": {id}})
+	if err != nil {
+		return nil, err
+	}
+
+	images := Images{Posters: []Image{}, Backdrops: []Image{}}
+	if poster := omdbValue(title.Poster); len(poster) != 0 {
+		images.Posters = append(images.Posters, Image{Thumbnail: poster, Image: poster})
+	}
+	return &images, nil
+}
+
+func (o *OMDb) SearchPerson(query string) ([]PersonResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (o *OMDb) Person(id string) (*Person, error) {
+	return nil, ErrNotSupported
+}
+
+func (o *OMDb) title(params url.Values) (*omdbTitle, error) {
+	title := omdbTitle{}
+	if err := o.get(params, &title); err != nil {
+		return nil, err
+	}
+	return &title, nil
+}
+
+// get decodes the response, OMDb responds 200 with an Error when the request fails
+func (o *OMDb) get(params url.Values, v interface{}) error {
+	params.Set("apikey", o.key)
+
+	body, err := getBody(o.client, o.Name(), o.baseURL+"/?"+params.Encode())
+	if err != nil {
+		return err
+	}
+
+	status := struct {
+		Response string `json:"Response"`
+		Error    string `json:"Error"`
+	}{}
+	if err := decode(o.Name(), body, &status); err != nil {
+		return err
+	}
+	if status.Response == "False" {
+		message := strings.ToLower(status.Error)
+		if strings.Contains(message, "not found") || strings.Contains(message, "incorrect imdb id") {
+			return ErrNotFound
+		}
+		return errors.New("omdb: " + status.Error)
+	}
+	return decode(o.Name(), body, v)
+}
+
+func omdbType(kind string) string {
+	if kind == "tv" {
+		return "series"
+	}
+	return kind
+}
+
+// omdbValue returns empty for N/A
+func omdbValue(value string) string {
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
+
+// omdbList splits a comma separated value, notes like "(screenplay)" are removed
+func omdbList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(omdbValue(value), ",") {
+		if i := strings.Index(item, "("); i >= 0 {
+			item = item[:i]
+		}
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// omdbDate converts 16 Jul 2010 to 2010-07-16, episodes of a season already have the latter
+func omdbDate(value string) string {
+	for _, layout := range []string{"02 Jan 2006", "2006-01-02"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+// omdbYear converts the first year of 2008–2013 to 2008-01-01
+func omdbYear(value string) string {
+	if len(value) < 4 {
+		return ""
+	}
+	if _, err := strconv.Atoi(value[:4]); err != nil {
+		return ""
+	}
+	return value[:4] + "-01-01"
+}
+
+// omdbMinutes converts 148 min to 148
+func omdbMinutes(value string) int {
+	return omdbNumber(strings.TrimSuffix(value, " min"))
+}
+
+func omdbNumber(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
+
+func omdbCertifications(rated string) []Certification {
+	if rated = omdbValue(rated); len(rated) == 0 || rated == "Not Rated" || rated == "Unrated" {
+		return nil
+	}
+	return []Certification{{Country: "US", Rating: rated}}
+}
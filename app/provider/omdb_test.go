@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// omdbFixtures responds the JSON of the i or s parameter, OMDb has a single path for every request
+func omdbFixtures(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("apikey") != "key" {
+			t.Errorf("%s sent without the api key", r.URL)
+		}
+		body, ok := responses[query.Get("i")+query.Get("s")]
+		if !ok {
+			body = `{"Response":"False","Error":"Incorrect IMDb ID."}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestOMDbSearch(t *testing.T) {
+	server := omdbFixtures(t, map[string]string{
+		"inception": `{"Search":[{"Title":"Inception","Year":"2010","imdbID":"tt1375666","Type":"movie","Poster":"https://img.test/inception.jpg"},
+			{"Title":"Inception: The Cobol Job","Year":"2010","imdbID":"tt5295894","Type":"movie","Poster":"N/A"}],"totalResults":"2","Response":"True"}`,
+		"nothing": `{"Response":"False","Error":"Movie not found!"}`,
+	})
+	defer server.Close()
+
+	omdb := NewOMDb(server.URL, "key")
+	results, err := omdb.Search("movie", "inception", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("%d results, want 2", len(results))
+	}
+	if results[0].ImdbID != "tt1375666" || results[0].Title != "Inception" || results[0].ReleaseDate != "2010-01-01" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if results[0].PosterURL != "https://img.test/inception.jpg" || results[1].PosterURL != "" {
+		t.Errorf("poster urls %q and %q, N/A should be empty", results[0].PosterURL, results[1].PosterURL)
+	}
+
+	results, err = omdb.Search("movie", "nothing", "")
+	if err != nil || len(results) != 0 {
+		t.Errorf("search without result: %v %v, want empty", results, err)
+	}
+}
+
+func TestOMDbMovie(t *testing.T) {
+	server := omdbFixtures(t, map[string]string{
+		"tt1375666": `{"Title":"Inception","Year":"2010","Rated":"PG-13","Released":"16 Jul 2010","Runtime":"148 min",
+			"Genre":"Action, Adventure, Sci-Fi","Director":"Christopher Nolan","Writer":"Christopher Nolan (screenplay)",
+			"Actors":"Leonardo DiCaprio, Joseph Gordon-Levitt","Plot":"A thief who steals corporate secrets.",
+			"Country":"USA, UK","Poster":"https://img.test/inception.jpg","imdbID":"tt1375666","Type":"movie",
+			"Production":"Warner Bros. Pictures","Response":"True"}`,
+	})
+	defer server.Close()
+
+	movie, err := NewOMDb(server.URL, "key").Movie("tt1375666")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Title != "Inception" || movie.ReleaseDate != "2010-07-16" || movie.Runtime != 148 {
+		t.Errorf("unexpected movie %+v", movie)
+	}
+	if len(movie.Genres) != 3 || movie.Genres[2].Name != "Sci-Fi" {
+		t.Errorf("genres %+v", movie.Genres)
+	}
+	if len(movie.Credits.Cast) != 2 || movie.Credits.Cast[1].Name != "Joseph Gordon-Levitt" || movie.Credits.Cast[1].Order != 1 {
+		t.Errorf("cast %+v", movie.Credits.Cast)
+	}
+	if len(movie.Credits.Crew) != 2 || movie.Credits.Crew[1].Name != "Christopher Nolan" || movie.Credits.Crew[1].Job != "Writer" {
+		t.Errorf("crew %+v, note of the writer should be removed", movie.Credits.Crew)
+	}
+	if len(movie.Certifications) != 1 || movie.Certifications[0] != (Certification{Country: "US", Rating: "PG-13"}) {
+		t.Errorf("certifications %+v", movie.Certifications)
+	}
+}
+
+func TestOMDbTv(t *testing.T) {
+	server := omdbFixtures(t, map[string]string{
+		"tt0944947": `{"Title":"Game of Thrones","Year":"2011–2019","Rated":"TV-MA","Released":"17 Apr 2011","Runtime":"57 min",
+			"Genre":"Action, Adventure, Drama","Writer":"David Benioff, D.B. Weiss","Actors":"Peter Dinklage, Lena Headey",
+			"Plot":"Nine noble families fight for control.","Poster":"N/A","imdbID":"tt0944947","Type":"series",
+			"totalSeasons":"8","Response":"True"}`,
+	})
+	defer server.Close()
+
+	tv, err := NewOMDb(server.URL, "key").Tv("tt0944947")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tv.Name != "Game of Thrones" || tv.FirstAirDate != "2011-04-17" || tv.NumberOfSeasons != 8 || len(tv.Seasons) != 8 {
+		t.Errorf("unexpected tv %+v", tv)
+	}
+	if len(tv.EpisodeRunTime) != 1 || tv.EpisodeRunTime[0] != 57 {
+		t.Errorf("episode run time %v", tv.EpisodeRunTime)
+	}
+	if len(tv.Casts) != 2 || len(tv.Credits.Cast) != 2 || tv.Credits.Cast[0].Name != "Peter Dinklage" {
+		t.Errorf("casts %v and %+v", tv.Casts, tv.Credits.Cast)
+	}
+	if len(tv.CreatedBy) != 2 || tv.CreatedBy[1].Name != "D.B. Weiss" {
+		t.Errorf("created by %+v", tv.CreatedBy)
+	}
+}
+
+func TestOMDbErrors(t *testing.T) {
+	server := omdbFixtures(t, map[string]string{
+		"tt0000001": `{"Response":"False","Error":"Request limit reached!"}`,
+	})
+	defer server.Close()
+
+	omdb := NewOMDb(server.URL, "key")
+	if _, err := omdb.Movie("tt0000000"); err != ErrNotFound {
+		t.Errorf("unknown id: %v, want ErrNotFound", err)
+	}
+	if _, err := omdb.Movie("tt0000001"); err == nil || err == ErrNotFound {
+		t.Errorf("request limit: %v, want the error of OMDb", err)
+	}
+	if _, err := omdb.Person("nm0634240"); err != ErrNotSupported {
+		t.Errorf("person: %v, want ErrNotSupported", err)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := NewOMDb(notFound.URL, "key").Tv("tt0944947"); err != ErrNotFound {
+		t.Errorf("404 response: %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/condrowiyono/ruangtengah-api/app/outbound"
+)
+
+var (
+	// ErrNotFound is returned when the provider has no such title or person
+	ErrNotFound = errors.New("not found on the provider")
+	// ErrNotSupported is returned when the provider doesn't have the data, ex: people of OMDb
+	ErrNotSupported = errors.New("not supported by the provider")
+)
+
+// Provider is a source of movie, tv and person metadata.
+// Kind is movie or tv, id is the provider own id, ex: 27205 on TMDB or tt1375666 on OMDb
+type Provider interface {
+	Name() string
+	Search(kind string, query string, year string) ([]SearchResult, error)
+	Movie(id string) (*Movie, error)
+	Tv(id string) (*Tv, error)
+	TvSeason(id string, season int) (*Season, error)
+	TvEpisode(id string, season int, episode int) (*Episode, error)
+	Images(kind string, id string) (*Images, error)
+	SearchPerson(query string) ([]PersonResult, error)
+	Person(id string) (*Person, error)
+}
+
+// Providers by name, ex: tmdb, omdb
+type Providers map[string]Provider
+
+// FromEnv creates every provider, base URLs can be changed to use a stand-in
+func FromEnv() Providers {
+	providers := Providers{}
+	for _, provider := range []Provider{TMDBFromEnv(), OMDbFromEnv()} {
+		providers[provider.Name()] = provider
+	}
+	return providers
+}
+
+// StatusError is an unexpected response status of the provider
+type StatusError struct {
+	Provider   string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded %d %s", e.Provider, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// RequestError is the provider not answering, ex: timeout or refused connection
+type RequestError struct {
+	Provider string
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+// Timeout tells whether the provider didn't answer before the deadline
+func (e *RequestError) Timeout() bool {
+	return outbound.IsTimeout(e.Err)
+}
+
+// HTTPStatus maps error of the provider to the response status, provider not answering in time is 504
+func HTTPStatus(err error) int {
+	if requestErr, ok := err.(*RequestError); ok && requestErr.Timeout() {
+		return http.StatusGatewayTimeout
+	}
+
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrNotSupported:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// getJSON decodes the response of url
+func getJSON(client *http.Client, name string, url string, v interface{}) error {
+	body, err := getBody(client, name, url)
+	if err != nil {
+		return err
+	}
+	return decode(name, body, v)
+}
+
+// getBody reads the response of url, error never includes the url since it has the api key
+func getBody(client *http.Client, name string, url string) ([]byte, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, &RequestError{Provider: name, Err: unwrapURLError(err)}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &StatusError{Provider: name, StatusCode: res.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, &RequestError{Provider: name, Err: err}
+	}
+	return body, nil
+}
+
+func decode(name string, body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
+// newClient create client retrying the provider, limiter is shared by every client of the provider
+func newClient(limiter *outbound.Limiter) *http.Client {
+	return outbound.NewClient(outbound.OptionsFromEnv(limiter))
+}
+
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); len(value) != 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/condrowiyono/ruangtengah-api/app/outbound"
+)
+
+// Default TMDB servers
+const (
+	TMDBBaseURL      = "https://api.themoviedb.org/3"
+	TMDBImageBaseURL = "https://image.tmdb.org/t/p"
+)
+
+// TMDB is The Movie Database
+type TMDB struct {
+	baseURL  string
+	imageURL string
+	key      string
+	client   *http.Client
+}
+
+// NewTMDB create TMDB provider, imageURL is the image server without size, ex: https://image.tmdb.org/t/p.
+// Requests are limited by TMDB_RATE_LIMIT, 40/1s by default
+func NewTMDB(baseURL string, imageURL string, key string) *TMDB {
+	limiter := outbound.Shared("tmdb", getenv("TMDB_RATE_LIMIT", "40/1s"))
+	return &TMDB{baseURL: baseURL, imageURL: imageURL, key: key, client: newClient(limiter)}
+}
+
+// TMDBFromEnv create TMDB provider of TMDB_KEY, TMDB_BASE_URL and TMDB_IMAGE_BASE_URL
+func TMDBFromEnv() *TMDB {
+	return NewTMDB(
+		getenv("TMDB_BASE_URL", TMDBBaseURL),
+		getenv("TMDB_IMAGE_BASE_URL", TMDBImageBaseURL),
+		os.Getenv("TMDB_KEY"),
+	)
+}
+
+func (t *TMDB) Name() string {
+	return "tmdb"
+}
+
+// Search non empty year narrows down the result
+func (t *TMDB) Search(kind string, query string, year string) ([]SearchResult, error) {
+	params := url.Values{"query": {query}}
+	if len(year) != 0 && kind == "tv" {
+		params.Set("first_air_date_year", year)
+	} else if len(year) != 0 {
+		params.Set("year", year)
+	}
+
+	search := struct {
+		Results []SearchResult `json:"results"`
+	}{}
+	if err := t.get("/search/"+url.PathEscape(kind), params, &search); err != nil {
+		return nil, err
+	}
+	for i := range search.Results {
+		search.Results[i].PosterURL = t.image("original", search.Results[i].PosterPath)
+	}
+	return search.Results, nil
+}
+
+// Movie gets the detail with its first 10 cast, directors, writers and certification of every country
+func (t *TMDB) Movie(id string) (*Movie, error) {
+	movie := Movie{}
+	params := url.Values{"append_to_response": {"credits,videos,release_dates"}}
+	if err := t.get("/movie/"+url.PathEscape(id), params, &movie); err != nil {
+		return nil, err
+	}
+	movie.PosterURL = t.image("original", movie.PosterPath)
+
+	// limit cast to only first 10
+	if len(movie.Credits.Cast) > 10 {
+		movie.Credits.Cast = movie.Credits.Cast[0:10]
+	}
+
+	// filter only director
+	var movieCrew []MovieCrew = nil
+
+	for _, crew := range movie.Credits.Crew {
+		if crew.Job == "Director" || crew.Job == "Writer" {
+			movieCrew = append(movieCrew, crew)
+		}
+	}
+
+	movie.Credits.Crew = movieCrew
+	movie.Credits = withTmdbIDs(movie.Credits)
+
+	// take first certification of every country
+	for _, country := range movie.ReleaseDates.Results {
+		for _, release := range country.ReleaseDates {
+			if len(release.Certification) > 0 {
+				movie.Certifications = append(movie.Certifications, Certification{
+					Country: country.Iso31661,
+					Rating:  release.Certification,
+				})
+				break
+			}
+		}
+	}
+
+	return &movie, nil
+}
+
+// Tv gets the detail with cast, videos and content ratings
+func (t *TMDB) Tv(id string) (*Tv, error) {
+	response := struct {
+		Tv
+		Videos struct {
+			Results []Video `json:"results"`
+		} `json:"videos"`
+		ContentRatings struct {
+			Results []struct {
+				Iso31661 string `json:"iso_3166_1"`
+				Rating   string `json:"rating"`
+			} `json:"results"`
+		} `json:"content_ratings"`
+	}{}
+	params := url.Values{"append_to_response": {"credits,videos,content_ratings"}}
+	if err := t.get("/tv/"+url.PathEscape(id), params, &response); err != nil {
+		return nil, err
+	}
+
+	tv := response.Tv
+	tv.PosterURL = t.image("original", tv.PosterPath)
+	// creators are already in created_by
+	tv.Credits.Crew = nil
+	tv.Credits = withTmdbIDs(tv.Credits)
+	for _, cast := range tv.Credits.Cast {
+		tv.Casts = append(tv.Casts, cast.Name)
+	}
+	for _, v := range response.ContentRatings.Results {
+		if len(v.Rating) > 0 {
+			tv.Certifications = append(tv.Certifications, Certification{Country: v.Iso31661, Rating: v.Rating})
+		}
+	}
+	tv.Videos = response.Videos.Results
+	return &tv, nil
+}
+
+// withTmdbIDs sets TmdbID of the cast and crew, their id is the TMDB person id
+func withTmdbIDs(credits Credits) Credits {
+	for i := range credits.Cast {
+		credits.Cast[i].TmdbID = credits.Cast[i].ID
+	}
+	for i := range credits.Crew {
+		credits.Crew[i].TmdbID = credits.Crew[i].ID
+	}
+	return credits
+}
+
+func (t *TMDB) TvSeason(id string, season int) (*Season, error) {
+	result := Season{}
+	if err := t.get(fmt.Sprintf("/tv/%s/season/%d", url.PathEscape(id), season), nil, &result); err != nil {
+		return nil, err
+	}
+	for i := range result.Episode {
+		result.Episode[i].StillURL = t.image("original", result.Episode[i].StillPath)
+	}
+	return &result, nil
+}
+
+func (t *TMDB) TvEpisode(id string, season int, episode int) (*Episode, error) {
+	result := Episode{}
+	if err := t.get(fmt.Sprintf("/tv/%s/season/%d/episode/%d", url.PathEscape(id), season, episode), nil, &result); err != nil {
+		return nil, err
+	}
+	result.StillURL = t.image("original", result.StillPath)
+	return &result, nil
+}
+
+// Images thumbnails are cropped like the TMDB website
+func (t *TMDB) Images(kind string, id string) (*Images, error) {
+	response := struct {
+		Backdrops []tmdbImage `json:"backdrops"`
+		Posters   []tmdbImage `json:"posters"`
+	}{}
+	if err := t.get("/"+url.PathEscape(kind)+"/"+url.PathEscape(id)+"/images", nil, &response); err != nil {
+		return nil, err
+	}
+
+	images := Images{Posters: []Image{}, Backdrops: []Image{}}
+	for _, v := range response.Backdrops {
+		images.Backdrops = append(images.Backdrops, v.image(t, "w500_and_h282_face"))
+	}
+	for _, v := range response.Posters {
+		images.Posters = append(images.Posters, v.image(t, "w220_and_h330_face"))
+	}
+	return &images, nil
+}
+
+func (t *TMDB) SearchPerson(query string) ([]PersonResult, error) {
+	search := struct {
+		Results []PersonResult `json:"results"`
+	}{}
+	if err := t.get("/search/person", url.Values{"query": {query}}, &search); err != nil {
+		return nil, err
+	}
+	for i := range search.Results {
+		search.Results[i].ProfileURL = t.image("original", search.Results[i].ProfilePath)
+	}
+	return search.Results, nil
+}
+
+func (t *TMDB) Person(id string) (*Person, error) {
+	person := Person{}
+	if err := t.get("/person/"+url.PathEscape(id), nil, &person); err != nil {
+		return nil, err
+	}
+	person.ProfileURL = t.image("original", person.ProfilePath)
+	return &person, nil
+}
+
+func (t *TMDB) get(path string, params url.Values, v interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("api_key", t.key)
+	return getJSON(t.client, t.Name(), t.baseURL+path+"?"+params.Encode(), v)
+}
+
+// image returns URL of the image path in the size, empty path has no URL
+func (t *TMDB) image(size string, path string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return t.imageURL + "/" + size + path
+}
+
+type tmdbImage struct {
+	FilePath string `json:"file_path"`
+	Height   int    `json:"height"`
+	Width    int    `json:"width"`
+}
+
+func (i tmdbImage) image(t *TMDB, thumbnailSize string) Image {
+	return Image{
+		Thumbnail: t.image(thumbnailSize, i.FilePath),
+		Image:     t.image("original", i.FilePath),
+		Width:     i.Width,
+		Height:    i.Height,
+	}
+}
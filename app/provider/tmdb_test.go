@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fixtures responds the JSON of the request path, other paths are 404 like the real APIs
+func fixtures(t *testing.T, key string, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != key {
+			t.Errorf("%s sent without the api key", r.URL.Path)
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestTMDBSearch(t *testing.T) {
+	server := fixtures(t, "key", map[string]string{
+		"/search/movie": `{"page":1,"results":[{"id":27205,"title":"Inception","poster_path":"/inception.jpg","backdrop_path":"/backdrop.jpg","release_date":"2010-07-15"}]}`,
+	})
+	defer server.Close()
+
+	results, err := NewTMDB(server.URL, "https://image.test/t/p", "key").Search("movie", "inception", "2010")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("%d results, want 1", len(results))
+	}
+	result := results[0]
+	if result.ID != 27205 || result.Title != "Inception" || result.ReleaseDate != "2010-07-15" || result.BannerPath != "/backdrop.jpg" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if result.PosterURL != "https://image.test/t/p/original/inception.jpg" {
+		t.Errorf("poster url %q", result.PosterURL)
+	}
+}
+
+func TestTMDBMovie(t *testing.T) {
+	cast := []string{}
+	for i := 0; i < 12; i++ {
+		cast = append(cast, fmt.Sprintf(`{"id": %d, "name": "Actor %d", "order": %d}`, 100+i, i, i))
+	}
+	server := fixtures(t, "key", map[string]string{
+		"/movie/27205": `{
+			"id": 27205, "imdb_id": "tt1375666", "title": "Inception", "runtime": 148, "poster_path": "/inception.jpg",
+			"genres": [{"id": 28, "name": "Action"}],
+			"credits": {
+				"cast": [` + strings.Join(cast, ",") + `],
+				"crew": [
+					{"id": 525, "name": "Christopher Nolan", "job": "Director", "department": "Directing"},
+					{"id": 525, "name": "Christopher Nolan", "job": "Writer", "department": "Writing"},
+					{"id": 947, "name": "Hans Zimmer", "job": "Original Music Composer", "department": "Sound"}
+				]
+			},
+			"videos": {"results": [{"key": "YoHD9XEInc0", "site": "YouTube", "type": "Trailer"}]},
+			"release_dates": {"results": [
+				{"iso_3166_1": "US", "release_dates": [{"certification": "", "type": 1}, {"certification": "PG-13", "type": 3}]},
+				{"iso_3166_1": "ID", "release_dates": [{"certification": "", "type": 3}]}
+			]}
+		}`,
+	})
+	defer server.Close()
+
+	movie, err := NewTMDB(server.URL, "https://image.test/t/p", "key").Movie("27205")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.ID != 27205 || movie.ImdbID != "tt1375666" || movie.Runtime != 148 || len(movie.Genres) != 1 {
+		t.Errorf("unexpected movie %+v", movie)
+	}
+	if movie.PosterURL != "https://image.test/t/p/original/inception.jpg" {
+		t.Errorf("poster url %q", movie.PosterURL)
+	}
+	if len(movie.Credits.Cast) != 10 {
+		t.Errorf("%d cast, want the first 10", len(movie.Credits.Cast))
+	}
+	if len(movie.Credits.Crew) != 2 {
+		t.Errorf("%d crew, want only director and writer", len(movie.Credits.Crew))
+	}
+	for _, crew := range movie.Credits.Crew {
+		if crew.TmdbID != 525 {
+			t.Errorf("tmdb id of %s is %d, want 525", crew.Job, crew.TmdbID)
+		}
+	}
+	if len(movie.Certifications) != 1 || movie.Certifications[0] != (Certification{Country: "US", Rating: "PG-13"}) {
+		t.Errorf("certifications %+v, want only US PG-13", movie.Certifications)
+	}
+}
+
+func TestTMDBTv(t *testing.T) {
+	server := fixtures(t, "key", map[string]string{
+		"/tv/1399": `{
+			"name": "Game of Thrones", "first_air_date": "2011-04-17", "number_of_seasons": 8,
+			"credits": {
+				"cast": [{"id": 22970, "name": "Peter Dinklage", "character": "Tyrion Lannister", "order": 0}],
+				"crew": [{"id": 9813, "name": "David Benioff", "job": "Executive Producer"}]
+			},
+			"videos": {"results": [{"key": "KPLWWIOCOOQ", "site": "YouTube", "type": "Trailer"}]},
+			"content_ratings": {"results": [{"iso_3166_1": "US", "rating": "TV-MA"}, {"iso_3166_1": "DE", "rating": ""}]}
+		}`,
+	})
+	defer server.Close()
+
+	tv, err := NewTMDB(server.URL, "https://image.test/t/p", "key").Tv("1399")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tv.Name != "Game of Thrones" || tv.NumberOfSeasons != 8 {
+		t.Errorf("unexpected tv %+v", tv)
+	}
+	if len(tv.Casts) != 1 || tv.Casts[0] != "Peter Dinklage" {
+		t.Errorf("casts %v", tv.Casts)
+	}
+	if len(tv.Credits.Cast) != 1 || tv.Credits.Cast[0].TmdbID != 22970 || tv.Credits.Cast[0].Character != "Tyrion Lannister" {
+		t.Errorf("credits cast %+v", tv.Credits.Cast)
+	}
+	if len(tv.Credits.Crew) != 0 {
+		t.Errorf("credits crew %+v, want none", tv.Credits.Crew)
+	}
+	if len(tv.Videos) != 1 || tv.Videos[0].Key != "KPLWWIOCOOQ" {
+		t.Errorf("videos %+v", tv.Videos)
+	}
+	if len(tv.Certifications) != 1 || tv.Certifications[0] != (Certification{Country: "US", Rating: "TV-MA"}) {
+		t.Errorf("certifications %+v, want only US TV-MA", tv.Certifications)
+	}
+}
+
+func TestTMDBNotFound(t *testing.T) {
+	server := fixtures(t, "key", map[string]string{})
+	defer server.Close()
+
+	tmdb := NewTMDB(server.URL, "https://image.test/t/p", "key")
+	if _, err := tmdb.Movie("0"); err != ErrNotFound {
+		t.Errorf("movie: %v, want ErrNotFound", err)
+	}
+	if _, err := tmdb.Tv("0"); err != ErrNotFound {
+		t.Errorf("tv: %v, want ErrNotFound", err)
+	}
+	if _, err := tmdb.Person("0"); err != ErrNotFound {
+		t.Errorf("person: %v, want ErrNotFound", err)
+	}
+}
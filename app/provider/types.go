@@ -0,0 +1,229 @@
+package provider
+
+// Shapes follow TMDB which the partner endpoints always returned.
+// Path fields are relative to the TMDB image server, URL fields are absolute for every provider
+
+// SearchResult is a movie or tv found by title, tv has its title in name
+type SearchResult struct {
+	PosterPath  string `json:"poster_path"`
+	BannerPath  string `json:"backdrop_path"`
+	PosterURL   string `json:"poster_url,omitempty"`
+	ID          int    `json:"id"`
+	ImdbID      string `json:"imdb_id,omitempty"`
+	Title       string `json:"title"`
+	Name        string `json:"name,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+}
+
+type Movie struct {
+	Adult               bool                       `json:"adult"`
+	BackdropPath        string                     `json:"backdrop_path"`
+	Genres              []MovieGenres              `json:"genres"`
+	ID                  int                        `json:"id"`
+	ImdbID              string                     `json:"imdb_id"`
+	Overview            string                     `json:"overview"`
+	PosterPath          string                     `json:"poster_path"`
+	PosterURL           string                     `json:"poster_url,omitempty"`
+	ProductionCompanies []MovieProductionCompanies `json:"production_companies"`
+	ProductionCountries []MovieProductionCountries `json:"production_countries"`
+	ReleaseDate         string                     `json:"release_date"`
+	Runtime             int                        `json:"runtime"`
+	Title               string                     `json:"title"`
+	Credits             Credits                    `json:"credits"`
+	Videos              Videos                     `json:"videos"`
+	ReleaseDates        ReleaseDates               `json:"release_dates"`
+	Certifications      []Certification            `json:"certifications"`
+}
+
+type MovieGenres struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type MovieProductionCompanies struct {
+	ID            int    `json:"id"`
+	LogoPath      string `json:"logo_path"`
+	Name          string `json:"name"`
+	OriginCountry string `json:"origin_country"`
+}
+
+type MovieProductionCountries struct {
+	Iso31661 string `json:"iso_3166_1"`
+	Name     string `json:"name"`
+}
+
+type MovieCast struct {
+	CastID      int    `json:"cast_id"`
+	Character   string `json:"character"`
+	CreditID    string `json:"credit_id"`
+	Gender      int    `json:"gender"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Order       int    `json:"order"`
+	ProfilePath string `json:"profile_path"`
+	TmdbID      int    `json:"tmdb_id,omitempty"`
+}
+
+type MovieCrew struct {
+	CreditID    string `json:"credit_id"`
+	Department  string `json:"department"`
+	Gender      int    `json:"gender"`
+	ID          int    `json:"id"`
+	Job         string `json:"job"`
+	Name        string `json:"name"`
+	ProfilePath string `json:"profile_path"`
+	TmdbID      int    `json:"tmdb_id,omitempty"`
+}
+
+// Credits of a movie or tv. TmdbID of the cast and crew is the TMDB person id, sent back
+// with the actors and crews of the title to link the same person, ex: when imported by ImportPerson
+type Credits struct {
+	Cast []MovieCast `json:"cast"`
+	Crew []MovieCrew `json:"crew"`
+}
+
+type Results struct {
+	ID       string `json:"id"`
+	Iso6391  string `json:"iso_639_1"`
+	Iso31661 string `json:"iso_3166_1"`
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Site     string `json:"site"`
+	Size     int    `json:"size"`
+	Type     string `json:"type"`
+}
+
+type Videos struct {
+	Results []Results `json:"results"`
+}
+
+type ReleaseDates struct {
+	Results []struct {
+		Iso31661     string `json:"iso_3166_1"`
+		ReleaseDates []struct {
+			Certification string `json:"certification"`
+			Type          int    `json:"type"`
+		} `json:"release_dates"`
+	} `json:"results"`
+}
+
+// Certification is age rating of a country, same shape as model.Certification
+type Certification struct {
+	Country string `json:"country"`
+	Rating  string `json:"rating"`
+}
+
+type Tv struct {
+	BackdropPath        string                `json:"backdrop_path"`
+	CreatedBy           []CreatedBy           `json:"created_by"`
+	EpisodeRunTime      []int                 `json:"episode_run_time"`
+	FirstAirDate        string                `json:"first_air_date"`
+	Genres              []Genres              `json:"genres"`
+	ImdbID              string                `json:"imdb_id,omitempty"`
+	Name                string                `json:"name"`
+	Networks            []Networks            `json:"networks"`
+	NumberOfEpisodes    int                   `json:"number_of_episodes"`
+	NumberOfSeasons     int                   `json:"number_of_seasons"`
+	OriginCountry       []string              `json:"origin_country"`
+	Overview            string                `json:"overview"`
+	PosterPath          string                `json:"poster_path"`
+	PosterURL           string                `json:"poster_url,omitempty"`
+	ProductionCompanies []ProductionCompanies `json:"production_companies"`
+	Seasons             []Season              `json:"seasons"`
+	Casts               []string              `json:"casts"`
+	Credits             Credits               `json:"credits"`
+	Videos              []Video               `json:"videos"`
+	Certifications      []Certification       `json:"certifications"`
+}
+
+type CreatedBy struct {
+	ID          int    `json:"id"`
+	CreditID    string `json:"credit_id"`
+	Name        string `json:"name"`
+	Gender      int    `json:"gender"`
+	ProfilePath string `json:"profile_path"`
+}
+
+type Genres struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Networks struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	OriginCountry string `json:"origin_country"`
+}
+
+type ProductionCompanies struct {
+	Name          string `json:"name"`
+	OriginCountry string `json:"origin_country"`
+}
+
+type Season struct {
+	AirDate      string    `json:"air_date"`
+	EpisodeCount int       `json:"episode_count"`
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Overview     string    `json:"overview"`
+	Episode      []Episode `json:"episodes"`
+	PosterPath   string    `json:"poster_path"`
+	SeasonNumber int       `json:"season_number"`
+}
+
+type Video struct {
+	Key  string `json:"key"`
+	Site string `json:"site"`
+	Type string `json:"type"`
+}
+
+type Episode struct {
+	AirDate        string  `json:"air_date"`
+	EpisodeNumber  int     `json:"episode_number"`
+	Name           string  `json:"name"`
+	Overview       string  `json:"overview"`
+	ID             int     `json:"id"`
+	ProductionCode string  `json:"production_code"`
+	SeasonNumber   int     `json:"season_number"`
+	StillPath      string  `json:"still_path"`
+	StillURL       string  `json:"still_url,omitempty"`
+	VoteAverage    float64 `json:"vote_average"`
+	VoteCount      int     `json:"vote_count"`
+}
+
+// Images of a movie or tv, banners are the backdrops
+type Images struct {
+	Posters   []Image `json:"posters"`
+	Backdrops []Image `json:"backdrops"`
+}
+
+// Image is an image of the provider with its thumbnail, size is zero when unknown
+type Image struct {
+	Thumbnail string `json:"thumbnail"`
+	Image     string `json:"image"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+}
+
+// PersonResult is a person found by name
+type PersonResult struct {
+	ID                 int     `json:"id"`
+	Name               string  `json:"name"`
+	KnownForDepartment string  `json:"known_for_department"`
+	Popularity         float64 `json:"popularity"`
+	ProfilePath        string  `json:"profile_path"`
+	ProfileURL         string  `json:"profile_url,omitempty"`
+}
+
+type Person struct {
+	ID                 int    `json:"id"`
+	ImdbID             string `json:"imdb_id"`
+	Name               string `json:"name"`
+	Biography          string `json:"biography"`
+	Birthday           string `json:"birthday"`
+	Deathday           string `json:"deathday"`
+	PlaceOfBirth       string `json:"place_of_birth"`
+	KnownForDepartment string `json:"known_for_department"`
+	ProfilePath        string `json:"profile_path"`
+	ProfileURL         string `json:"profile_url,omitempty"`
+}
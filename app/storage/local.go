@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores files in a directory served by the app under /uploads/
+type Local struct {
+	Dir     string
+	baseURL string
+}
+
+// NewLocal create local storage in dir, URL is prefixed by baseURL
+func NewLocal(dir string, baseURL string) *Local {
+	if len(dir) == 0 {
+		dir = "uploads"
+	}
+	return &Local{Dir: dir, baseURL: baseURL}
+}
+
+// Put writes to a temporary file first so reader never sees a partial file
+func (l *Local) Put(key string, data io.Reader, contentType string) error {
+	filename := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tempFile, data); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+	os.Chmod(tempFile.Name(), 0644)
+	return os.Rename(tempFile.Name(), filename)
+}
+
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	file, err := os.Open(l.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (l *Local) Delete(key string) error {
+	err := os.Remove(l.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/uploads/" + strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+// List walks the directory, temporary file of unfinished Put is skipped
+func (l *Local) List(prefix string) ([]Object, error) {
+	objects := []Object{}
+	err := filepath.Walk(l.Dir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.Dir, filename)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	return objects, err
+}
+
+// path maps key into the directory, key can not leave it
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(path.Clean("/"+key)))
+}
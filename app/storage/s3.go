@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/config"
+)
+
+const (
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3DateFormat    = "20060102"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DefaultRegion = "us-east-1"
+)
+
+// S3 stores files in a bucket of S3 compatible service, ex: AWS S3 or MinIO.
+// Requests are signed with AWS Signature Version 4
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	pathStyle bool
+	client    *http.Client
+}
+
+// NewS3 create S3 storage, path style addressing is needed by most self hosted service
+func NewS3(config *config.StorageConfig) (*S3, error) {
+	if len(config.Bucket) == 0 {
+		return nil, errors.New("s3 bucket is required")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || len(endpoint.Host) == 0 {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+
+	region := config.Region
+	if len(region) == 0 {
+		region = s3DefaultRegion
+	}
+
+	return &S3{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    config.Bucket,
+		accessKey: config.AccessKey,
+		secretKey: config.SecretKey,
+		publicURL: strings.TrimSuffix(config.PublicURL, "/"),
+		pathStyle: config.PathStyle,
+		client:    &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (s *S3) Put(key string, data io.Reader, contentType string) error {
+	body, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(http.MethodPut, key, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return s3Error(res)
+}
+
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	res, err := s.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s3Error(res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *S3) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return s3Error(res)
+}
+
+// List pages through ListObjectsV2 of the bucket
+func (s *S3) List(prefix string) ([]Object, error) {
+	objects := []Object{}
+	token := ""
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if len(token) != 0 {
+			query["continuation-token"] = token
+		}
+
+		res, err := s.doBucket(http.MethodGet, query)
+		if err != nil {
+			return nil, err
+		}
+		if err := s3Error(res); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+
+		result := struct {
+			Contents []struct {
+				Key          string
+				Size         int64
+				LastModified time.Time
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}{}
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, Size: content.Size, ModTime: content.LastModified})
+		}
+		if !result.IsTruncated {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// URL returns public URL of the key, the bucket must allow public read
+func (s *S3) URL(key string) string {
+	if len(s.publicURL) != 0 {
+		return s.publicURL + "/" + escapePath(key)
+	}
+	return s.objectURL(key).String()
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	object := *s.endpoint
+	if s.pathStyle {
+		object.Path = "/" + s.bucket + "/" + key
+	} else {
+		object.Host = s.bucket + "." + s.endpoint.Host
+		object.Path = "/" + key
+	}
+	object.RawPath = escapePath(object.Path)
+	return &object
+}
+
+// doBucket sends signed request of the bucket, query is sorted and encoded as the signature needs
+func (s *S3) doBucket(method string, query map[string]string) (*http.Response, error) {
+	names := []string{}
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := []string{}
+	for _, name := range names {
+		params = append(params, escapeQuery(name)+"="+escapeQuery(query[name]))
+	}
+
+	bucket := s.objectURL("")
+	bucket.RawQuery = strings.Join(params, "&")
+
+	req, err := http.NewRequest(method, bucket.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// do sends signed request of the object
+func (s *S3) do(method string, key string, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if len(contentType) != 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 authorization header
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if len(req.Header.Get("Content-Type")) != 0 {
+		signedHeaders = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	}
+
+	canonicalRequest := s3CanonicalRequest(req, signedHeaders, payloadHash)
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+// s3CanonicalRequest returns the request as it is signed, the query must be already sorted and encoded
+func s3CanonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	var canonicalHeaders strings.Builder
+	for _, header := range signedHeaders {
+		canonicalHeaders.WriteString(header + ":" + strings.TrimSpace(req.Header.Get(header)) + "\n")
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// s3Error converts error response to error
+func s3Error(res *http.Response) error {
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		message, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("s3: %s: %s", res.Status, message)
+	}
+	return nil
+}
+
+// escapePath URI encode every segment following AWS rule, slash is kept
+func escapePath(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			builder.WriteByte(b)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+// escapeQuery is escapePath which also encodes slash
+func escapeQuery(value string) string {
+	return strings.Replace(escapePath(value), "/", "%2F", -1)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/condrowiyono/ruangtengah-api/config"
+)
+
+// Storage drivers
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// ErrNotFound is returned when the key doesn't exist
+var ErrNotFound = errors.New("file not found")
+
+// Storage keeps uploaded files, key is a slash separated path, ex: images/upload-123.jpg
+type Storage interface {
+	Put(key string, data io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	URL(key string) string
+}
+
+// Object is a stored file
+type Object struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Lister is a storage which can list its files, keys are returned in no particular order
+type Lister interface {
+	List(prefix string) ([]Object, error)
+}
+
+// New create storage of the configured driver, local is the default
+func New(config *config.StorageConfig) (Storage, error) {
+	switch config.Driver {
+	case "", DriverLocal:
+		return NewLocal(config.LocalDir, config.BaseURL), nil
+	case DriverS3:
+		return NewS3(config)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", config.Driver)
+}
+
+// NewKey returns unique key under prefix, ex: images/upload-1589000000-9f86d081.jpg
+func NewKey(prefix string, name string, ext string) string {
+	random := make([]byte, 4)
+	rand.Read(random)
+	return path.Join(prefix, fmt.Sprintf("%s-%d-%s%s", name, time.Now().Unix(), hex.EncodeToString(random), ext))
+}
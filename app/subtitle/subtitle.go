@@ -0,0 +1,255 @@
+package subtitle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported subtitle formats
+const (
+	FormatSRT = "srt"
+	FormatASS = "ass"
+	FormatVTT = "vtt"
+)
+
+// Cue is a single subtitle text shown between Start and End
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+var (
+	timingLine   = regexp.MustCompile(`^\s*(\S+)\s+-->\s+(\S+)`)
+	assOverride  = regexp.MustCompile(`\{[^}]*\}`)
+	errEmptyFile = errors.New("subtitle has no cue")
+)
+
+// DetectFormat guess subtitle format from file name, then from its content
+func DetectFormat(filename string, data []byte) string {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")) {
+	case "srt":
+		return FormatSRT
+	case "ass", "ssa":
+		return FormatASS
+	case "vtt":
+		return FormatVTT
+	}
+
+	text := string(normalize(data))
+	switch {
+	case strings.HasPrefix(text, "WEBVTT"):
+		return FormatVTT
+	case strings.Contains(text, "[Script Info]") || strings.Contains(text, "[Events]"):
+		return FormatASS
+	}
+	return FormatSRT
+}
+
+// Parse read cues of the given format
+func Parse(data []byte, format string) ([]Cue, error) {
+	var cues []Cue
+	var err error
+
+	switch format {
+	case FormatSRT, FormatVTT:
+		cues, err = parseBlocks(normalize(data))
+	case FormatASS:
+		cues, err = parseASS(normalize(data))
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if len(cues) == 0 {
+		return nil, errEmptyFile
+	}
+	return cues, nil
+}
+
+// Shift move every cue by offset, cue which ends before zero is dropped
+func Shift(cues []Cue, offset time.Duration) []Cue {
+	shifted := []Cue{}
+	for _, cue := range cues {
+		cue.Start += offset
+		cue.End += offset
+		if cue.End <= 0 {
+			continue
+		}
+		if cue.Start < 0 {
+			cue.Start = 0
+		}
+		shifted = append(shifted, cue)
+	}
+	return shifted
+}
+
+// WebVTT render cues as WebVTT file
+func WebVTT(cues []Cue) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&buffer, "%s --> %s\n%s\n\n", formatTimestamp(cue.Start), formatTimestamp(cue.End), cue.Text)
+	}
+	return buffer.Bytes()
+}
+
+// ToWebVTT convert subtitle of any supported format to WebVTT, shifted by offset
+func ToWebVTT(data []byte, format string, offset time.Duration) ([]byte, error) {
+	cues, err := Parse(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return WebVTT(Shift(cues, offset)), nil
+}
+
+// normalize strip BOM and windows line ending
+func normalize(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+}
+
+// parseBlocks parse SRT and WebVTT, both are blank line separated block with timing line.
+// Block without timing line (index, header, NOTE, STYLE) is skipped
+func parseBlocks(data []byte) ([]Cue, error) {
+	cues := []Cue{}
+	for _, block := range strings.Split(string(data), "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		for i, line := range lines {
+			match := timingLine.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+
+			start, err := parseTimestamp(match[1])
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimestamp(match[2])
+			if err != nil {
+				return nil, err
+			}
+
+			text := strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+			if len(text) > 0 {
+				cues = append(cues, Cue{Start: start, End: end, Text: text})
+			}
+			break
+		}
+	}
+	return cues, nil
+}
+
+// parseASS parse Dialogue lines of [Events] section following its Format line
+func parseASS(data []byte) ([]Cue, error) {
+	cues := []Cue{}
+	inEvents := false
+	fields := []string{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+
+		if strings.HasPrefix(line, "Format:") {
+			fields = strings.Split(strings.TrimPrefix(line, "Format:"), ",")
+			for i := range fields {
+				fields[i] = strings.ToLower(strings.TrimSpace(fields[i]))
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "Dialogue:") || len(fields) == 0 {
+			continue
+		}
+
+		// Text is the last field and may contains comma
+		values := strings.SplitN(strings.TrimPrefix(line, "Dialogue:"), ",", len(fields))
+		if len(values) != len(fields) {
+			continue
+		}
+
+		cue := Cue{}
+		for i, field := range fields {
+			value := strings.TrimSpace(values[i])
+			var err error
+			switch field {
+			case "start":
+				cue.Start, err = parseTimestamp(value)
+			case "end":
+				cue.End, err = parseTimestamp(value)
+			case "text":
+				cue.Text = assText(values[i])
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(cue.Text) > 0 {
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+// assText remove override tags and convert ASS line break
+func assText(text string) string {
+	text = assOverride.ReplaceAllString(text, "")
+	text = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(text)
+	return strings.TrimSpace(text)
+}
+
+// parseTimestamp parse hh:mm:ss,mmm (SRT), hh:mm:ss.mmm or mm:ss.mmm (WebVTT) and h:mm:ss.cc (ASS)
+func parseTimestamp(value string) (time.Duration, error) {
+	value = strings.Replace(value, ",", ".", 1)
+
+	fraction := "0"
+	if i := strings.Index(value, "."); i >= 0 {
+		value, fraction = value[:i], value[i+1:]
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+
+	var total time.Duration
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		total = total*60 + time.Duration(number)*time.Second
+	}
+
+	// fraction is padded to millisecond, ex: ASS centisecond 50 is 500ms
+	fraction = (fraction + "000")[:3]
+	millisecond, err := strconv.Atoi(fraction)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	return total + time.Duration(millisecond)*time.Millisecond, nil
+}
+
+// formatTimestamp format duration as WebVTT hh:mm:ss.mmm
+func formatTimestamp(duration time.Duration) string {
+	millisecond := int64(duration / time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		millisecond/3600000,
+		millisecond/60000%60,
+		millisecond/1000%60,
+		millisecond%1000)
+}
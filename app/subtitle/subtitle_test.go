@@ -0,0 +1,128 @@
+package subtitle
+
+import (
+	"testing"
+	"time"
+)
+
+const srt = "\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nTwo\r\nlines\r\n\r\n3\r\n00:00:05,000 --> 00:00:06,000\r\n\r\n"
+
+const vtt = `WEBVTT
+
+NOTE written by hand
+
+00:01.000 --> 00:02.500 align:start
+Hello
+
+cue-2
+01:00:03.000 --> 01:00:04.000
+World
+`
+
+const ass = `[Script Info]
+Title: Example
+
+[V4+ Styles]
+Format: Name, Fontname
+Style: Default,Arial
+
+[Events]
+Format: Layer, Start, End, Style, Text
+Dialogue: 0,0:00:01.00,0:00:02.50,Default,{\i1}Hello{\i0}, world
+Dialogue: 0,0:00:03.00,0:00:04.00,Default,Two\Nlines
+Comment: 0,0:00:05.00,0:00:06.00,Default,Skipped
+`
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		data     string
+		want     string
+	}{
+		{"movie.srt", vtt, FormatSRT},
+		{"movie.SSA", "", FormatASS},
+		{"movie.vtt", "", FormatVTT},
+		{"upload", vtt, FormatVTT},
+		{"upload", ass, FormatASS},
+		{"upload", srt, FormatSRT},
+	}
+	for _, test := range tests {
+		if got := DetectFormat(test.filename, []byte(test.data)); got != test.want {
+			t.Errorf("%s detected as %s, want %s", test.filename, got, test.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   []Cue
+	}{
+		{"srt", srt, FormatSRT, []Cue{
+			{time.Second, 2500 * time.Millisecond, "Hello"},
+			{3 * time.Second, 4 * time.Second, "Two\nlines"},
+		}},
+		{"vtt", vtt, FormatVTT, []Cue{
+			{time.Second, 2500 * time.Millisecond, "Hello"},
+			{time.Hour + 3*time.Second, time.Hour + 4*time.Second, "World"},
+		}},
+		{"ass", ass, FormatASS, []Cue{
+			{time.Second, 2500 * time.Millisecond, "Hello, world"},
+			{3 * time.Second, 4 * time.Second, "Two\nlines"},
+		}},
+	}
+	for _, test := range tests {
+		cues, err := Parse([]byte(test.data), test.format)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(cues) != len(test.want) {
+			t.Errorf("%s: %d cues %+v, want %d", test.name, len(cues), cues, len(test.want))
+			continue
+		}
+		for i := range cues {
+			if cues[i] != test.want[i] {
+				t.Errorf("%s: cue %d is %+v, want %+v", test.name, i, cues[i], test.want[i])
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse([]byte("1\n00:00:01,000 --> 00:00:02,000\n"), FormatSRT); err == nil {
+		t.Error("file without text parsed without error")
+	}
+	if _, err := Parse([]byte("1\n00:xx:01,000 --> 00:00:02,000\nHello\n"), FormatSRT); err == nil {
+		t.Error("invalid timestamp parsed without error")
+	}
+	if _, err := Parse([]byte(srt), "sub"); err == nil {
+		t.Error("unknown format parsed without error")
+	}
+}
+
+func TestToWebVTT(t *testing.T) {
+	data, err := ToWebVTT([]byte(srt), FormatSRT, -1500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n" +
+		"00:00:00.000 --> 00:00:01.000\nHello\n\n" +
+		"00:00:01.500 --> 00:00:02.500\nTwo\nlines\n\n"
+	if string(data) != want {
+		t.Errorf("converted to\n%s\nwant\n%s", data, want)
+	}
+
+	data, _ = ToWebVTT([]byte(srt), FormatSRT, -3*time.Second)
+	if string(data) != "WEBVTT\n\n00:00:00.000 --> 00:00:01.000\nTwo\nlines\n\n" {
+		t.Errorf("cue ending before zero should be dropped, got\n%s", data)
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	if got := formatTimestamp(26*time.Hour + 3*time.Minute + 4*time.Second + 5*time.Millisecond); got != "26:03:04.005" {
+		t.Errorf("formatted as %s", got)
+	}
+}
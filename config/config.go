@@ -8,7 +8,8 @@ import (
 )
 
 type Config struct {
-	DB *DBConfig
+	DB      *DBConfig
+	Storage *StorageConfig
 }
 
 type DBConfig struct {
@@ -21,6 +22,20 @@ type DBConfig struct {
 	Charset  string
 }
 
+// StorageConfig choose where uploaded files are kept, Driver is local or s3
+type StorageConfig struct {
+	Driver    string
+	LocalDir  string
+	BaseURL   string
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string
+	PathStyle bool
+}
+
 func GetConfig() *Config {
 	err := godotenv.Load()
 	if err != nil {
@@ -36,5 +51,17 @@ func GetConfig() *Config {
 			Name:     os.Getenv("DB_NAME"),
 			Charset:  os.Getenv("DB_CHARSET"),
 		},
+		Storage: &StorageConfig{
+			Driver:    os.Getenv("STORAGE_DRIVER"),
+			LocalDir:  os.Getenv("STORAGE_LOCAL_DIR"),
+			BaseURL:   os.Getenv("BASE_URL"),
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		},
 	}
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/condrowiyono/ruangtengah-api/app"
 	"github.com/condrowiyono/ruangtengah-api/config"
@@ -11,6 +12,13 @@ func main() {
 	config := config.GetConfig()
 
 	app := &app.App{}
+
+	// go run main.go scan: match media library files to the catalog then exit
+	if len(os.Args) > 1 && os.Args[1] == "scan" {
+		app.ScanLibraryOnce(config)
+		return
+	}
+
 	app.Initialize(config)
 	app.Run(":9000")
 	fmt.Printf("Runserver on 9000")